@@ -17,7 +17,12 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 
 package cache
 
-import "time"
+import (
+	"io"
+	"time"
+
+	"github.com/iTrellis/common/codec"
+)
 
 // Cache Manager functions for executing k-v tables base on TableCache
 type Cache interface {
@@ -47,4 +52,46 @@ type Cache interface {
 	Members(tab string) ([]interface{}, bool)
 	// Set key Key expire time in the table Tab.
 	SetExpire(tab string, key interface{}, expire time.Duration) bool
+	// Returns the keys whose values have the index key in the named index of the table Tab.
+	LookupIndex(tab, index string, indexKey interface{}) ([]interface{}, bool)
+	// Calls fn with every key and its values in the table Tab until fn returns false.
+	Range(tab string, fn func(key interface{}, values []interface{}) bool)
+	// Returns the key-values matched by the predicate in the table Tab.
+	Select(tab string, predicate func(key interface{}, values []interface{}) bool) (map[interface{}][]interface{}, bool)
+	// Saves all tables with their value modes, values and remaining ttl.
+	Save(w io.Writer) error
+	// Restores the saved tables, the values of the existing tables are replaced, the missing tables are created.
+	Load(r io.Reader) error
+	// Stops the periodic snapshot, saves the last one and stops the background routines of tables.
+	Close() error
+}
+
+// CacheOptions the options of the cache manager
+type CacheOptions struct {
+	// codec of snapshots, default: json
+	Codec codec.Codec
+	// the file of periodic snapshots
+	SnapshotFile string
+	// interval of periodic snapshots, 0 is saved only when the cache is closed
+	SnapshotInterval time.Duration
+}
+
+// CacheOptionFunc the function to set the options of the cache manager
+type CacheOptionFunc func(*CacheOptions)
+
+// CacheOptionCodec set the codec of snapshots,
+// Unmarshal of the codec should return *Snapshot
+func CacheOptionCodec(c codec.Codec) CacheOptionFunc {
+	return func(o *CacheOptions) {
+		o.Codec = c
+	}
+}
+
+// CacheOptionSnapshot set the snapshot file which is restored when the cache is created,
+// and saved every interval
+func CacheOptionSnapshot(file string, interval time.Duration) CacheOptionFunc {
+	return func(o *CacheOptions) {
+		o.SnapshotFile = file
+		o.SnapshotInterval = interval
+	}
 }
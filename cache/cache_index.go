@@ -0,0 +1,129 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+// IndexFunc returns the index key of the value, false if the value is not indexed;
+// index keys should be comparable
+type IndexFunc func(value interface{}) (interface{}, bool)
+
+// OptionIndex add the named secondary index of values, keys are looked up by LookupIndex
+func OptionIndex(name string, fn IndexFunc) OptionFunc {
+	return func(t *Options) {
+		if t.Indexes == nil {
+			t.Indexes = make(map[string]IndexFunc)
+		}
+		t.Indexes[name] = fn
+	}
+}
+
+// tableIndexes keeps the keys of every index key of the secondary indexes,
+// the nil tableIndexes keeps nothing
+type tableIndexes struct {
+	funcs map[string]IndexFunc
+	// name => index key => keys
+	keys map[string]map[interface{}]map[interface{}]struct{}
+}
+
+func newTableIndexes(funcs map[string]IndexFunc) *tableIndexes {
+	if len(funcs) == 0 {
+		return nil
+	}
+	p := &tableIndexes{funcs: funcs}
+	p.reset()
+	return p
+}
+
+// add indexes the values of key
+func (p *tableIndexes) add(key interface{}, values []interface{}) {
+	if p == nil {
+		return
+	}
+	for name, fn := range p.funcs {
+		index := p.keys[name]
+		for _, v := range values {
+			ik, ok := fn(v)
+			if !ok {
+				continue
+			}
+			keys, ok := index[ik]
+			if !ok {
+				keys = make(map[interface{}]struct{})
+				index[ik] = keys
+			}
+			keys[key] = struct{}{}
+		}
+	}
+}
+
+// remove forgets the indexed values of key
+func (p *tableIndexes) remove(key interface{}, values []interface{}) {
+	if p == nil {
+		return
+	}
+	for name, fn := range p.funcs {
+		index := p.keys[name]
+		for _, v := range values {
+			ik, ok := fn(v)
+			if !ok {
+				continue
+			}
+			if keys, ok := index[ik]; ok {
+				delete(keys, key)
+				if len(keys) == 0 {
+					delete(index, ik)
+				}
+			}
+		}
+	}
+}
+
+// lookup calls fn with the keys of the index key
+func (p *tableIndexes) lookup(name string, ik interface{}, fn func(key interface{})) {
+	if p == nil {
+		return
+	}
+	for key := range p.keys[name][ik] {
+		fn(key)
+	}
+}
+
+func (p *tableIndexes) reset() {
+	if p == nil {
+		return
+	}
+	p.keys = make(map[string]map[interface{}]map[interface{}]struct{}, len(p.funcs))
+	for name := range p.funcs {
+		p.keys[name] = make(map[interface{}]map[interface{}]struct{})
+	}
+}
+
+// selectRange collects the key-values matched by the predicate in the range of the table
+func selectRange(tc TableCache, predicate func(key interface{}, values []interface{}) bool) (
+	items map[interface{}][]interface{}, ok bool) {
+	tc.Range(func(key interface{}, values []interface{}) bool {
+		if predicate(key, values) {
+			if items == nil {
+				items = make(map[interface{}][]interface{})
+				ok = true
+			}
+			items[key] = values
+		}
+		return true
+	})
+	return
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+type member struct {
+	Name string
+	City string
+	Age  int
+}
+
+var byCity = cache.OptionIndex("city", func(value interface{}) (interface{}, bool) {
+	u, ok := value.(member)
+	return u.City, ok
+})
+
+func sortedKeys(keys []interface{}) []string {
+	var names []string
+	for _, k := range keys {
+		names = append(names, k.(string))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTableIndex(t *testing.T) {
+	for _, opts := range [][]cache.OptionFunc{
+		{byCity},
+		{byCity, cache.OptionPolicy(cache.PolicyLFU)},
+		{byCity, cache.OptionShards(4)},
+	} {
+		tab, err := cache.NewTableCache("members", opts...)
+		testutils.Ok(t, err)
+
+		tab.Insert("alice", member{Name: "alice", City: "paris", Age: 30})
+		tab.Insert("bob", member{Name: "bob", City: "paris", Age: 20})
+		tab.Insert("carol", member{Name: "carol", City: "tokyo", Age: 40})
+		tab.InsertExpire("dave", member{Name: "dave", City: "paris", Age: 50}, time.Millisecond)
+		time.Sleep(time.Millisecond * 2)
+
+		keys, ok := tab.LookupIndex("city", "paris")
+		testutils.Assert(t, ok, "paris should be indexed")
+		testutils.Equals(t, []string{"alice", "bob"}, sortedKeys(keys))
+
+		// the index is updated by the new value of the key
+		tab.Insert("bob", member{Name: "bob", City: "tokyo", Age: 20})
+		keys, _ = tab.LookupIndex("city", "paris")
+		testutils.Equals(t, []string{"alice"}, sortedKeys(keys))
+		keys, _ = tab.LookupIndex("city", "tokyo")
+		testutils.Equals(t, []string{"bob", "carol"}, sortedKeys(keys))
+
+		tab.DeleteObject("alice")
+		_, ok = tab.LookupIndex("city", "paris")
+		testutils.Assert(t, !ok, "deleted alice should not be indexed")
+		_, ok = tab.LookupIndex("country", "japan")
+		testutils.Assert(t, !ok, "unknown index should not be found")
+
+		items, ok := tab.Select(func(_ interface{}, values []interface{}) bool {
+			return values[0].(member).Age > 30
+		})
+		testutils.Assert(t, ok, "carol should be selected")
+		testutils.Equals(t, map[interface{}][]interface{}{"carol": {member{Name: "carol", City: "tokyo", Age: 40}}}, items)
+
+		n := 0
+		tab.Range(func(interface{}, []interface{}) bool {
+			n++
+			return false
+		})
+		testutils.Equals(t, 1, n)
+
+		tab.DeleteObjects()
+		_, ok = tab.LookupIndex("city", "tokyo")
+		testutils.Assert(t, !ok, "index should be empty")
+	}
+}
+
+func TestTableIndexBag(t *testing.T) {
+	tab, err := cache.NewTableCache("tags", cache.OptionKeySize(2), cache.OptionValueMode(cache.ValueModeBag),
+		cache.OptionIndex("tag", func(value interface{}) (interface{}, bool) { return value, true }))
+	testutils.Ok(t, err)
+
+	tab.Insert("a", "go")
+	tab.Insert("a", "cache")
+	tab.Insert("b", "go")
+	keys, _ := tab.LookupIndex("tag", "go")
+	testutils.Equals(t, []string{"a", "b"}, sortedKeys(keys))
+	keys, _ = tab.LookupIndex("tag", "cache")
+	testutils.Equals(t, []string{"a"}, sortedKeys(keys))
+
+	// the evicted key is removed from the index
+	tab.Insert("c", "rust")
+	keys, _ = tab.LookupIndex("tag", "go")
+	testutils.Equals(t, []string{"b"}, sortedKeys(keys))
+	_, ok := tab.LookupIndex("tag", "cache")
+	testutils.Assert(t, !ok, "evicted a should not be indexed")
+}
+
+func TestGenericTableIndex(t *testing.T) {
+	tab, err := cache.NewTable[string, member]("members",
+		cache.OptionTableIndex("city", func(u member) (interface{}, bool) { return u.City, true }))
+	testutils.Ok(t, err)
+
+	tab.Insert("alice", member{Name: "alice", City: "paris", Age: 30})
+	tab.Insert("bob", member{Name: "bob", City: "tokyo", Age: 20})
+
+	keys, ok := tab.LookupIndex("city", "paris")
+	testutils.Assert(t, ok, "paris should be indexed")
+	testutils.Equals(t, []string{"alice"}, keys)
+
+	items, ok := tab.Select(func(_ string, values []member) bool { return values[0].Age < 30 })
+	testutils.Assert(t, ok, "bob should be selected")
+	testutils.Equals(t, map[string][]member{"bob": {{Name: "bob", City: "tokyo", Age: 20}}}, items)
+
+	c := cache.New()
+	testutils.Ok(t, c.New("members", byCity))
+	c.Insert("members", "alice", member{Name: "alice", City: "paris"})
+	found, ok := c.LookupIndex("members", "city", "paris")
+	testutils.Assert(t, ok, "paris should be indexed")
+	testutils.Equals(t, []interface{}{"alice"}, found)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iTrellis/common/codec"
+)
+
+// Snapshot is the saved tables of the cache
+type Snapshot struct {
+	Tables []*TableSnapshot `json:"tables"`
+}
+
+// TableSnapshot is the saved values of a table
+type TableSnapshot struct {
+	Name      string           `json:"name"`
+	ValueMode ValueMode        `json:"value_mode"`
+	Entries   []*EntrySnapshot `json:"entries"`
+}
+
+// EntrySnapshot is the saved values of a key, TTL is the remaining time to live, 0 is no expire
+type EntrySnapshot struct {
+	Key    interface{}   `json:"key"`
+	Values []interface{} `json:"values"`
+	TTL    time.Duration `json:"ttl,omitempty"`
+}
+
+// NewSnapshotCodec returns the default gob codec of snapshots, the types of keys and values are kept,
+// the types which are not the basic ones should be registered by gob.Register
+func NewSnapshotCodec() codec.Codec {
+	return codec.NewGobCodec("cache_snapshot", func() interface{} { return &Snapshot{} })
+}
+
+// snapshotTable is a table which can be saved
+type snapshotTable interface {
+	// snapshot returns the values which are not expired, the least recently used ones are first
+	snapshot() *TableSnapshot
+}
+
+// snapshot returns the values and the remaining ttl, false if it is expired
+func (p *DataValues) snapshot() (*EntrySnapshot, bool) {
+	entry := &EntrySnapshot{Key: p.Key, Values: append([]interface{}{}, p.Values...)}
+	if p.Expire != nil {
+		entry.TTL = time.Until(*p.Expire)
+		if entry.TTL <= 0 {
+			return nil, false
+		}
+	}
+	return entry, true
+}
+
+// restore inserts the saved values into the table
+func (p *TableSnapshot) restore(tc TableCache) {
+	for _, entry := range p.Entries {
+		for _, v := range entry.Values {
+			if entry.TTL > 0 {
+				tc.InsertExpire(entry.Key, v, entry.TTL)
+			} else {
+				tc.Insert(entry.Key, v)
+			}
+		}
+	}
+}
+
+func (p *gemCache) Save(w io.Writer) error {
+	s := &Snapshot{}
+	for _, tab := range p.All() {
+		tc, ok := p.GetTableCache(tab)
+		if !ok {
+			continue
+		}
+		st, ok := tc.(snapshotTable)
+		if !ok {
+			continue
+		}
+		ts := st.snapshot()
+		ts.Name = tab
+		s.Tables = append(s.Tables, ts)
+	}
+
+	bs, err := p.options.Codec.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bs)
+	return err
+}
+
+func (p *gemCache) Load(r io.Reader) error {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	v, err := p.options.Codec.Unmarshal(bs)
+	if err != nil {
+		return err
+	}
+
+	var s *Snapshot
+	switch t := v.(type) {
+	case *Snapshot:
+		s = t
+	case Snapshot:
+		s = &t
+	default:
+		return ErrUnknownSnapshot
+	}
+
+	for _, ts := range s.Tables {
+		tc, ok := p.GetTableCache(ts.Name)
+		if !ok {
+			err := p.New(ts.Name, OptionValueMode(ts.ValueMode))
+			if err != nil && err != ErrTableExists {
+				return err
+			}
+			p.Lock()
+			if err == nil {
+				p.restored[ts.Name] = true
+			}
+			tc, ok = p.tables[ts.Name]
+			p.Unlock()
+			if !ok {
+				continue
+			}
+		}
+		// the saved values replace the values of the table
+		tc.DeleteObjects()
+		ts.restore(tc)
+	}
+	return nil
+}
+
+func (p *gemCache) Close() error {
+	p.closeOnce.Do(func() {
+		if p.stopSnapshot != nil {
+			close(p.stopSnapshot)
+			<-p.snapshotDone
+		}
+	})
+
+	var err error
+	if p.options.SnapshotFile != "" {
+		err = p.saveFile()
+	}
+
+	p.Lock()
+	for _, tc := range p.tables {
+		tc.Close()
+	}
+	p.Unlock()
+	return err
+}
+
+// runSnapshot saves the snapshot into the file every interval
+func (p *gemCache) runSnapshot() {
+	defer close(p.snapshotDone)
+
+	ticker := time.NewTicker(p.options.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = p.saveFile()
+		case <-p.stopSnapshot:
+			return
+		}
+	}
+}
+
+// saveFile writes the snapshot into a temporary file and syncs it, then renames it to the snapshot file,
+// so the snapshot file is never half written
+func (p *gemCache) saveFile() error {
+	p.fileLocker.Lock()
+	defer p.fileLocker.Unlock()
+
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf); err != nil {
+		return err
+	}
+
+	tmp := p.options.SnapshotFile + ".tmp"
+	if err := writeFileSync(tmp, buf.Bytes()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p.options.SnapshotFile); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(p.options.SnapshotFile))
+}
+
+// writeFileSync writes the bytes into the file and flushes it to the disk
+func writeFileSync(name string, bs []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(bs); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// syncDir flushes the renaming of the file in the directory to the disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// loadFile restores the snapshot file if it exists
+func (p *gemCache) loadFile() error {
+	p.fileLocker.Lock()
+	defer p.fileLocker.Unlock()
+
+	bs, _, err := p.files.Read(p.options.SnapshotFile)
+	_ = p.files.Close(p.options.SnapshotFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return p.Load(bytes.NewReader(bs))
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/codec"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestSaveLoad(t *testing.T) {
+	c := cache.New()
+	testutils.Ok(t, c.New("users", cache.OptionKeySize(10)))
+	testutils.Ok(t, c.New("tags", cache.OptionValueMode(cache.ValueModeBag), cache.OptionShards(2)))
+
+	c.Insert("users", "a", "alice")
+	c.InsertExpire("users", "b", "bob", time.Hour)
+	c.InsertExpire("users", "c", "carol", time.Millisecond)
+	c.Insert("tags", "go", "fast")
+	c.Insert("tags", "go", "simple")
+	time.Sleep(time.Millisecond * 2)
+
+	buf := &bytes.Buffer{}
+	testutils.Ok(t, c.Save(buf))
+
+	restored := cache.New()
+	testutils.Ok(t, restored.Load(bytes.NewReader(buf.Bytes())))
+	testutils.Equals(t, []string{"tags", "users"}, restored.All())
+
+	values, ok := restored.Lookup("users", "a")
+	testutils.Assert(t, ok, "a should be restored")
+	testutils.Equals(t, []interface{}{"alice"}, values)
+	testutils.Assert(t, !restored.Member("users", "c"), "expired c should not be saved")
+	values, _ = restored.Lookup("tags", "go")
+	testutils.Equals(t, []interface{}{"fast", "simple"}, values)
+
+	// the remaining ttl is restored
+	buf.Reset()
+	testutils.Ok(t, restored.Save(buf))
+	v, err := cache.NewSnapshotCodec().Unmarshal(buf.Bytes())
+	testutils.Ok(t, err)
+	for _, tab := range v.(*cache.Snapshot).Tables {
+		for _, entry := range tab.Entries {
+			if entry.Key == "b" {
+				testutils.Assert(t, entry.TTL > 0 && entry.TTL < time.Hour, "ttl of b is %s", entry.TTL)
+			} else {
+				testutils.Equals(t, time.Duration(0), entry.TTL)
+			}
+		}
+	}
+
+	// the restored table is created again with options and keeps its values
+	testutils.Ok(t, restored.New("users", cache.OptionKeySize(2)))
+	testutils.ErrorEqual(t, cache.ErrTableExists, restored.New("users"))
+	values, _ = restored.Lookup("users", "a")
+	testutils.Equals(t, []interface{}{"alice"}, values)
+
+	c = cache.New()
+	testutils.Ok(t, c.New("users"))
+	testutils.Ok(t, c.Load(bytes.NewReader(buf.Bytes())))
+	testutils.Assert(t, c.Member("users", "a"), "a should be restored into the existing table")
+
+	c, err = cache.NewCache(cache.CacheOptionCodec(codec.String{}))
+	testutils.Ok(t, err)
+	testutils.ErrorEqual(t, cache.ErrUnknownSnapshot, c.Load(strings.NewReader("{}")))
+}
+
+type snapshotUser struct {
+	Name string
+	Age  int
+}
+
+func init() {
+	gob.Register(snapshotUser{})
+}
+
+func TestSaveLoadTypes(t *testing.T) {
+	c := cache.New()
+	testutils.Ok(t, c.New("users"))
+	testutils.Ok(t, c.New("dups", cache.OptionValueMode(cache.ValueModeDuplicateBag)))
+	c.Insert("users", 1, snapshotUser{Name: "alice", Age: 20})
+	c.Insert("dups", "a", 1)
+	c.Insert("dups", "a", 1)
+
+	buf := &bytes.Buffer{}
+	testutils.Ok(t, c.Save(buf))
+
+	// the types of keys and values are kept
+	restored := cache.New()
+	testutils.Ok(t, restored.Load(bytes.NewReader(buf.Bytes())))
+	values, ok := restored.Lookup("users", 1)
+	testutils.Assert(t, ok, "the int key 1 should be restored")
+	testutils.Equals(t, []interface{}{snapshotUser{Name: "alice", Age: 20}}, values)
+
+	// the values of the existing tables are replaced
+	c.Insert("dups", "b", 2)
+	testutils.Ok(t, c.Load(bytes.NewReader(buf.Bytes())))
+	values, _ = c.Lookup("dups", "a")
+	testutils.Equals(t, []interface{}{1, 1}, values)
+	testutils.Assert(t, !c.Member("dups", "b"), "b should be removed by loading")
+}
+
+func TestSnapshotFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	c, err := cache.NewCache(cache.CacheOptionSnapshot(file, time.Millisecond*5))
+	testutils.Ok(t, err)
+	testutils.Ok(t, c.New("users"))
+	c.Insert("users", "a", "alice")
+	time.Sleep(time.Millisecond * 20)
+
+	warm, err := cache.NewCache(cache.CacheOptionSnapshot(file, 0))
+	testutils.Ok(t, err)
+	testutils.Assert(t, warm.Member("users", "a"), "a should be saved periodically")
+
+	c.Insert("users", "b", "bob")
+	testutils.Ok(t, c.Close())
+
+	warm, err = cache.NewCache(cache.CacheOptionSnapshot(file, 0))
+	testutils.Ok(t, err)
+	testutils.Assert(t, warm.Member("users", "b"), "b should be saved when the cache is closed")
+	_, err = os.Stat(file + ".tmp")
+	testutils.Assert(t, os.IsNotExist(err), "the temporary file should be renamed: %v", err)
+	testutils.Ok(t, warm.Close())
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLoadBuckets are the upper bounds of the buckets of load durations
+var defaultLoadBuckets = []time.Duration{
+	time.Millisecond, time.Millisecond * 5, time.Millisecond * 10, time.Millisecond * 25,
+	time.Millisecond * 50, time.Millisecond * 100, time.Millisecond * 250, time.Millisecond * 500,
+	time.Second, time.Millisecond * 2500, time.Second * 5, time.Second * 10,
+}
+
+// Stats counters of a table
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Inserts uint64
+	// keys evicted because the table is full
+	Evictions   uint64
+	Expirations uint64
+	Deletions   uint64
+	// number of keys in the table, expired keys which are not removed yet are counted
+	Size int
+	// total cost of values in the table
+	Cost int64
+
+	// counters of the loading table
+	Loads      uint64
+	LoadErrors uint64
+	LoadTime   time.Duration
+	// upper bounds of the buckets of load durations
+	LoadBuckets []time.Duration
+	// cumulative numbers of loads which are not longer than LoadBuckets
+	LoadDurations []uint64
+	// number of loads counted in the buckets, including the ones longer than all the buckets
+	LoadCount uint64
+}
+
+// HitRatio returns hits / (hits + misses)
+func (p Stats) HitRatio() float64 {
+	total := p.Hits + p.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(p.Hits) / float64(total)
+}
+
+// add returns the sum of the stats
+func (p Stats) add(s Stats) Stats {
+	p.Hits += s.Hits
+	p.Misses += s.Misses
+	p.Inserts += s.Inserts
+	p.Evictions += s.Evictions
+	p.Expirations += s.Expirations
+	p.Deletions += s.Deletions
+	p.Size += s.Size
+	p.Cost += s.Cost
+	p.Loads += s.Loads
+	p.LoadErrors += s.LoadErrors
+	p.LoadTime += s.LoadTime
+	p.LoadCount += s.LoadCount
+	if len(s.LoadDurations) > 0 {
+		p.LoadBuckets = s.LoadBuckets
+		durations := make([]uint64, len(s.LoadDurations))
+		copy(durations, p.LoadDurations)
+		for i, n := range s.LoadDurations {
+			durations[i] += n
+		}
+		p.LoadDurations = durations
+	}
+	return p
+}
+
+// statsCounter counts the stats of a table concurrently
+type statsCounter struct {
+	hits        uint64
+	misses      uint64
+	inserts     uint64
+	evictions   uint64
+	expirations uint64
+	deletions   uint64
+	loads       uint64
+	loadErrors  uint64
+	loadTime    int64
+	// bounds are the upper bounds of the buckets,
+	// loadBuckets has the numbers of loads of each bucket, the last one is over all the buckets,
+	// they are nil if the table does not load
+	bounds      []time.Duration
+	loadBuckets []uint64
+}
+
+func newLoadStatsCounter() *statsCounter {
+	return &statsCounter{
+		bounds:      defaultLoadBuckets,
+		loadBuckets: make([]uint64, len(defaultLoadBuckets)+1),
+	}
+}
+
+func (p *statsCounter) hit() {
+	atomic.AddUint64(&p.hits, 1)
+}
+
+func (p *statsCounter) miss() {
+	atomic.AddUint64(&p.misses, 1)
+}
+
+func (p *statsCounter) insert() {
+	atomic.AddUint64(&p.inserts, 1)
+}
+
+func (p *statsCounter) removed(reason EvictReason) {
+	switch reason {
+	case EvictReasonCapacity:
+		atomic.AddUint64(&p.evictions, 1)
+	case EvictReasonExpired:
+		atomic.AddUint64(&p.expirations, 1)
+	case EvictReasonDeleted:
+		atomic.AddUint64(&p.deletions, 1)
+	}
+}
+
+func (p *statsCounter) load(d time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&p.loadErrors, 1)
+	} else {
+		atomic.AddUint64(&p.loads, 1)
+	}
+	atomic.AddInt64(&p.loadTime, int64(d))
+	if p.loadBuckets == nil {
+		return
+	}
+	i := sort.Search(len(p.bounds), func(i int) bool { return d <= p.bounds[i] })
+	atomic.AddUint64(&p.loadBuckets[i], 1)
+}
+
+func (p *statsCounter) stats(size int, cost int64) Stats {
+	stats := Stats{
+		Hits:        atomic.LoadUint64(&p.hits),
+		Misses:      atomic.LoadUint64(&p.misses),
+		Inserts:     atomic.LoadUint64(&p.inserts),
+		Evictions:   atomic.LoadUint64(&p.evictions),
+		Expirations: atomic.LoadUint64(&p.expirations),
+		Deletions:   atomic.LoadUint64(&p.deletions),
+		Size:        size,
+		Cost:        cost,
+		Loads:       atomic.LoadUint64(&p.loads),
+		LoadErrors:  atomic.LoadUint64(&p.loadErrors),
+		LoadTime:    time.Duration(atomic.LoadInt64(&p.loadTime)),
+	}
+	if p.loadBuckets == nil {
+		return stats
+	}
+	stats.LoadBuckets = make([]time.Duration, len(p.bounds))
+	copy(stats.LoadBuckets, p.bounds)
+	stats.LoadDurations = make([]uint64, len(p.bounds))
+	var n uint64
+	for i := range p.bounds {
+		n += atomic.LoadUint64(&p.loadBuckets[i])
+		stats.LoadDurations[i] = n
+	}
+	stats.LoadCount = n + atomic.LoadUint64(&p.loadBuckets[len(p.bounds)])
+	return stats
+}
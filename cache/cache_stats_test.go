@@ -0,0 +1,91 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestStats(t *testing.T) {
+	for _, opts := range [][]cache.OptionFunc{
+		{cache.OptionKeySize(100)},
+		{cache.OptionKeySize(100), cache.OptionPolicy(cache.PolicyLFU)},
+		{cache.OptionKeySize(100), cache.OptionShards(4)},
+	} {
+		tab, err := cache.NewTableCache("stats", opts...)
+		testutils.Ok(t, err)
+
+		tab.Insert("a", 1)
+		tab.Insert("b", 2)
+		tab.Lookup("a")
+		tab.Lookup("c")
+		tab.InsertExpire("d", 4, time.Millisecond)
+		time.Sleep(time.Millisecond * 2)
+		tab.Lookup("d")
+		tab.DeleteObject("b")
+
+		s := tab.Stats()
+		testutils.Equals(t, uint64(1), s.Hits)
+		testutils.Equals(t, uint64(2), s.Misses)
+		testutils.Equals(t, uint64(3), s.Inserts)
+		testutils.Equals(t, uint64(0), s.Evictions)
+		testutils.Equals(t, uint64(1), s.Expirations)
+		testutils.Equals(t, uint64(1), s.Deletions)
+		testutils.Equals(t, 1, s.Size)
+		testutils.Equals(t, float64(1)/3, s.HitRatio())
+	}
+
+	tab, err := cache.NewTableCache("evictions", cache.OptionKeySize(1))
+	testutils.Ok(t, err)
+	tab.Insert("a", 1)
+	tab.Insert("b", 2)
+	testutils.Equals(t, uint64(1), tab.Stats().Evictions)
+}
+
+func TestLoadingStats(t *testing.T) {
+	tab, err := cache.NewLoadingTable("loading")
+	testutils.Ok(t, err)
+
+	tab.GetOrLoad("a", func(key interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return 1, nil
+	})
+	tab.GetOrLoad("a", nil)
+	tab.GetOrLoad("b", func(key interface{}) (interface{}, error) { return nil, errors.New("failed") })
+
+	s := tab.Stats()
+	testutils.Equals(t, uint64(1), s.Hits)
+	testutils.Equals(t, uint64(2), s.Misses)
+	testutils.Equals(t, uint64(1), s.Loads)
+	testutils.Equals(t, uint64(1), s.LoadErrors)
+	testutils.Assert(t, s.LoadTime >= time.Millisecond, "load time %s", s.LoadTime)
+	testutils.Equals(t, 1, s.Size)
+
+	testutils.Equals(t, len(s.LoadBuckets), len(s.LoadDurations))
+	testutils.Equals(t, uint64(2), s.LoadDurations[len(s.LoadDurations)-1])
+	testutils.Equals(t, uint64(2), s.LoadCount)
+
+	// the bounds of stats are copied
+	s.LoadBuckets[0] = time.Hour
+	testutils.Assert(t, tab.Stats().LoadBuckets[0] < time.Hour, "bounds of the table should not be changed")
+}
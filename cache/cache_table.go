@@ -18,7 +18,11 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cache
 
 import (
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/iTrellis/common/formats"
 )
 
 // Timers
@@ -29,6 +33,33 @@ const (
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback func(key interface{}, value interface{})
 
+// CostFunc returns the cost of the value, exp: the bytes of the value
+type CostFunc func(key interface{}, value interface{}) int64
+
+// EvictReason the reason why a cache entry is removed
+type EvictReason int
+
+const (
+	// EvictReasonCapacity the entry is evicted because the table is full
+	EvictReasonCapacity EvictReason = iota
+	// EvictReasonExpired the entry is expired
+	EvictReasonExpired
+	// EvictReasonDeleted the entry is deleted by DeleteObject or DeleteObjects
+	EvictReasonDeleted
+)
+
+func (p EvictReason) String() string {
+	switch p {
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
 // TableCache table manager for k-vs functions
 type TableCache interface {
 	// Inserts the object or all of the objects in list.
@@ -49,6 +80,16 @@ type TableCache interface {
 	LookupAll() (map[interface{}][]interface{}, bool)
 	// Set Key Expire time
 	SetExpire(key interface{}, expire time.Duration) bool
+	// Returns the keys whose values have the index key in the named index.
+	LookupIndex(index string, indexKey interface{}) ([]interface{}, bool)
+	// Calls fn with every key and its values until fn returns false, the table is locked while iterating.
+	Range(fn func(key interface{}, values []interface{}) bool)
+	// Returns the key-values matched by the predicate.
+	Select(predicate func(key interface{}, values []interface{}) bool) (map[interface{}][]interface{}, bool)
+	// Returns the counters of the table.
+	Stats() Stats
+	// Stop the background routines of the table.
+	Close()
 }
 
 // OptionFunc 参数处理函数
@@ -59,8 +100,34 @@ type Options struct {
 	ValueMode ValueMode
 
 	Size int
+	// eviction policy when the table is full, default is LRU
+	Policy Policy
+	// number of independently locked shards, keys are hashed across them
+	Shards int
+	// max total cost of values, 0 is unlimit
+	MaxCost int64
+	// cost of a value, the cost of every value is 1 if it is nil
+	Cost CostFunc
+	// named secondary indexes of values
+	Indexes map[string]IndexFunc
 
+	// called on every removed entry
 	Evict EvictCallback
+	// called on the entry evicted because the table is full
+	OnCapacity EvictCallback
+	// called on the expired entry
+	OnExpire EvictCallback
+	// called on the entry deleted by DeleteObject or DeleteObjects
+	OnDelete EvictCallback
+
+	// interval of sweeping expired entries in background, 0 is lazy expiry on reads only
+	ExpireInterval time.Duration
+
+	// options of the loading table
+	Loader       LoaderFunc
+	LoadTTL      time.Duration
+	NegativeTTL  time.Duration
+	RefreshAhead time.Duration
 }
 
 // OptionValueMode set the values' model
@@ -77,9 +144,130 @@ func OptionKeySize(size int) OptionFunc {
 	}
 }
 
+// OptionPolicy set the eviction policy
+func OptionPolicy(policy Policy) OptionFunc {
+	return func(t *Options) {
+		t.Policy = policy
+	}
+}
+
+// OptionMaxCost set the max total cost of values by byte size, exp: 512MB, 1gib;
+// the values' costs are got by OptionCost
+func OptionMaxCost(size string) OptionFunc {
+	return func(t *Options) {
+		size = strings.ToLower(strings.TrimSpace(size))
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+			t.MaxCost = n
+			return
+		}
+		cost := formats.ParseStringByteSize(size)
+		if cost == nil || !cost.IsInt64() {
+			t.MaxCost = -1
+			return
+		}
+		t.MaxCost = cost.Int64()
+	}
+}
+
+// OptionCost set the cost function of values
+func OptionCost(cost CostFunc) OptionFunc {
+	return func(t *Options) {
+		t.Cost = cost
+	}
+}
+
+// OptionShards hash keys across n independently locked shards,
+// the size is divided into the shards and evictions are decided per shard
+func OptionShards(n int) OptionFunc {
+	return func(t *Options) {
+		t.Shards = n
+	}
+}
+
 // OptionEvict set the evict ballback
 func OptionEvict(evict EvictCallback) OptionFunc {
 	return func(t *Options) {
 		t.Evict = evict
 	}
 }
+
+// OptionOnCapacity set the callback of entries evicted because the table is full
+func OptionOnCapacity(evict EvictCallback) OptionFunc {
+	return func(t *Options) {
+		t.OnCapacity = evict
+	}
+}
+
+// OptionOnExpire set the callback of expired entries
+func OptionOnExpire(evict EvictCallback) OptionFunc {
+	return func(t *Options) {
+		t.OnExpire = evict
+	}
+}
+
+// OptionOnDelete set the callback of entries deleted by DeleteObject or DeleteObjects
+func OptionOnDelete(evict EvictCallback) OptionFunc {
+	return func(t *Options) {
+		t.OnDelete = evict
+	}
+}
+
+// OptionExpireInterval sweep the expired entries in background every interval
+func OptionExpireInterval(interval time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.ExpireInterval = interval
+	}
+}
+
+// runJanitor calls sweep every interval until stop is closed
+func runJanitor(interval time.Duration, stop <-chan struct{}, sweep func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			sweep()
+		}
+	}
+}
+
+// cost returns the cost of the value
+func (p *Options) cost(key, value interface{}) int64 {
+	if p.Cost == nil {
+		return 1
+	}
+	return p.Cost(key, value)
+}
+
+// check checks the limits of the table
+func (p *Options) check() error {
+	if p.Size < 0 {
+		return ErrInvalidTableSize
+	}
+	if p.MaxCost < 0 {
+		return ErrInvalidTableCost
+	}
+	return nil
+}
+
+// callback call the callbacks of the reason
+func (p *Options) callback(key, value interface{}, reason EvictReason) {
+	if p.Evict != nil {
+		p.Evict(key, value)
+	}
+
+	var fn EvictCallback
+	switch reason {
+	case EvictReasonCapacity:
+		fn = p.OnCapacity
+	case EvictReasonExpired:
+		fn = p.OnExpire
+	case EvictReasonDeleted:
+		fn = p.OnDelete
+	}
+	if fn != nil {
+		fn(key, value)
+	}
+}
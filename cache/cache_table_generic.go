@@ -0,0 +1,213 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"time"
+)
+
+// Table is a type safe table cache with keys of K and values of V,
+// it wraps a TableCache, such as the LRU table
+type Table[K comparable, V any] struct {
+	tc TableCache
+}
+
+// NewTable constructs a type safe table with the options of NewTableCache
+func NewTable[K comparable, V any](name string, opts ...OptionFunc) (*Table[K, V], error) {
+	tc, err := NewTableCache(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return WrapTable[K, V](tc), nil
+}
+
+// WrapTable return a type safe table of the table cache,
+// values which are not K or V in the table cache are ignored
+func WrapTable[K comparable, V any](tc TableCache) *Table[K, V] {
+	return &Table[K, V]{tc: tc}
+}
+
+// GetTable return the type safe table of tab in the cache
+func GetTable[K comparable, V any](c Cache, tab string) (*Table[K, V], bool) {
+	tc, ok := c.GetTableCache(tab)
+	if !ok {
+		return nil, false
+	}
+	return WrapTable[K, V](tc), true
+}
+
+// OptionTableEvict set the type safe evict callback of the table
+func OptionTableEvict[K comparable, V any](evict func(key K, values []V)) OptionFunc {
+	return OptionEvict(func(key interface{}, value interface{}) {
+		k, _ := key.(K)
+		vs, _ := value.([]interface{})
+		evict(k, toValues[V](vs))
+	})
+}
+
+// OptionTableIndex add the type safe secondary index of values of V
+func OptionTableIndex[V any](name string, fn func(value V) (interface{}, bool)) OptionFunc {
+	return OptionIndex(name, func(value interface{}) (interface{}, bool) {
+		v, ok := value.(V)
+		if !ok {
+			return nil, false
+		}
+		return fn(v)
+	})
+}
+
+// TableCache return the wrapped table cache
+func (p *Table[K, V]) TableCache() TableCache {
+	return p.tc
+}
+
+// Insert inserts the value with key.
+func (p *Table[K, V]) Insert(key K, value V) bool {
+	return p.tc.Insert(key, value)
+}
+
+// InsertExpire inserts the value with key and expired time.
+func (p *Table[K, V]) InsertExpire(key K, value V, expire time.Duration) bool {
+	return p.tc.InsertExpire(key, value, expire)
+}
+
+// DeleteObject deletes all values with key.
+func (p *Table[K, V]) DeleteObject(key K) bool {
+	return p.tc.DeleteObject(key)
+}
+
+// DeleteObjects deletes all values in the table.
+func (p *Table[K, V]) DeleteObjects() {
+	p.tc.DeleteObjects()
+}
+
+// Member returns true if the table has key, otherwise false.
+func (p *Table[K, V]) Member(key K) bool {
+	return p.tc.Member(key)
+}
+
+// Members returns all keys in the table.
+func (p *Table[K, V]) Members() ([]K, bool) {
+	members, ok := p.tc.Members()
+	if !ok {
+		return nil, false
+	}
+	keys := make([]K, 0, len(members))
+	for _, m := range members {
+		if k, ok := m.(K); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+// Lookup looks up values with key.
+func (p *Table[K, V]) Lookup(key K) ([]V, bool) {
+	vs, ok := p.tc.Lookup(key)
+	if !ok {
+		return nil, false
+	}
+	return toValues[V](vs), true
+}
+
+// Get returns the first value with key, it is the value of unique mode table.
+func (p *Table[K, V]) Get(key K) (v V, ok bool) {
+	vs, ok := p.Lookup(key)
+	if !ok || len(vs) == 0 {
+		return v, false
+	}
+	return vs[0], true
+}
+
+// LookupAll looks up all key-values in the table.
+func (p *Table[K, V]) LookupAll() (map[K][]V, bool) {
+	all, ok := p.tc.LookupAll()
+	if !ok {
+		return nil, false
+	}
+	items := make(map[K][]V, len(all))
+	for key, vs := range all {
+		if k, ok := key.(K); ok {
+			items[k] = toValues[V](vs)
+		}
+	}
+	return items, len(items) > 0
+}
+
+// LookupIndex looks up keys with the index key in the named index.
+func (p *Table[K, V]) LookupIndex(index string, indexKey interface{}) ([]K, bool) {
+	found, ok := p.tc.LookupIndex(index, indexKey)
+	if !ok {
+		return nil, false
+	}
+	keys := make([]K, 0, len(found))
+	for _, f := range found {
+		if k, ok := f.(K); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+// Range calls fn with every key-values until fn returns false.
+func (p *Table[K, V]) Range(fn func(key K, values []V) bool) {
+	p.tc.Range(func(key interface{}, values []interface{}) bool {
+		k, ok := key.(K)
+		if !ok {
+			return true
+		}
+		return fn(k, toValues[V](values))
+	})
+}
+
+// Select looks up key-values matched by the predicate.
+func (p *Table[K, V]) Select(predicate func(key K, values []V) bool) (map[K][]V, bool) {
+	items := make(map[K][]V)
+	p.Range(func(key K, values []V) bool {
+		if predicate(key, values) {
+			items[key] = values
+		}
+		return true
+	})
+	return items, len(items) > 0
+}
+
+// SetExpire sets the expired time of key.
+func (p *Table[K, V]) SetExpire(key K, expire time.Duration) bool {
+	return p.tc.SetExpire(key, expire)
+}
+
+// Stats returns the counters of the table.
+func (p *Table[K, V]) Stats() Stats {
+	return p.tc.Stats()
+}
+
+// Close stops the background routines of the table.
+func (p *Table[K, V]) Close() {
+	p.tc.Close()
+}
+
+func toValues[V any](vs []interface{}) []V {
+	values := make([]V, 0, len(vs))
+	for _, v := range vs {
+		if tv, ok := v.(V); ok {
+			values = append(values, tv)
+		}
+	}
+	return values
+}
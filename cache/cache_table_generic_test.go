@@ -0,0 +1,89 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestTable(t *testing.T) {
+	var evicted []int
+	tab, err := cache.NewTable[int, *user]("users",
+		cache.OptionKeySize(2),
+		cache.OptionTableEvict(func(key int, values []*user) {
+			testutils.Equals(t, 1, len(values))
+			evicted = append(evicted, key)
+		}))
+	testutils.Ok(t, err)
+
+	testutils.Assert(t, tab.Insert(1, &user{ID: 1, Name: "a"}), "insert 1")
+	testutils.Assert(t, tab.Insert(2, &user{ID: 2, Name: "b"}), "insert 2")
+	testutils.Assert(t, tab.Insert(3, &user{ID: 3, Name: "c"}), "insert 3")
+	testutils.Equals(t, []int{1}, evicted)
+
+	u, ok := tab.Get(2)
+	testutils.Assert(t, ok, "2 should exist")
+	testutils.Equals(t, "b", u.Name)
+
+	_, ok = tab.Get(1)
+	testutils.Assert(t, !ok, "1 should be evicted")
+
+	keys, ok := tab.Members()
+	testutils.Assert(t, ok, "members should exist")
+	sort.Ints(keys)
+	testutils.Equals(t, []int{2, 3}, keys)
+
+	all, ok := tab.LookupAll()
+	testutils.Assert(t, ok, "all should exist")
+	testutils.Equals(t, "c", all[3][0].Name)
+
+	testutils.Assert(t, tab.DeleteObject(3), "delete 3")
+	testutils.Assert(t, !tab.Member(3), "3 should be deleted")
+}
+
+func TestGetTable(t *testing.T) {
+	c := cache.New()
+	testutils.Ok(t, c.New("tags", cache.OptionValueMode(cache.ValueModeBag)))
+
+	tab, ok := cache.GetTable[string, string](c, "tags")
+	testutils.Assert(t, ok, "tags should exist")
+	tab.Insert("go", "fast")
+	tab.Insert("go", "simple")
+	tab.Insert("go", "fast")
+
+	vs, ok := tab.Lookup("go")
+	testutils.Assert(t, ok, "go should exist")
+	testutils.Equals(t, []string{"fast", "simple"}, vs)
+
+	// values inserted by the untyped cache are the same
+	c.Insert("tags", "go", "typed")
+	vs, _ = tab.Lookup("go")
+	testutils.Equals(t, 3, len(vs))
+
+	_, ok = cache.GetTable[string, string](c, "unknown")
+	testutils.Assert(t, !ok, "unknown should not exist")
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestOptionMaxCost(t *testing.T) {
+	for size, cost := range map[string]int64{"100": 100, "1KB": 1000, "1kib": 1024, " 2MiB ": 2 << 20} {
+		opts := &cache.Options{}
+		cache.OptionMaxCost(size)(opts)
+		testutils.Equals(t, cost, opts.MaxCost)
+	}
+
+	_, err := cache.NewTableCache("cost", cache.OptionMaxCost("1 apple"))
+	testutils.ErrorEqual(t, cache.ErrInvalidTableCost, err)
+}
+
+func TestTableCost(t *testing.T) {
+	byLength := cache.OptionCost(func(_, value interface{}) int64 { return int64(len(value.(string))) })
+
+	for _, policy := range []cache.Policy{cache.PolicyLRU, cache.PolicyLFU, cache.PolicyARC, cache.Policy2Q, cache.PolicyTinyLFU} {
+		tab, err := cache.NewTableCache("cost", cache.OptionMaxCost("10"), byLength, cache.OptionPolicy(policy))
+		testutils.Ok(t, err)
+
+		tab.Insert("a", "aaaa")
+		tab.Insert("b", "bbbb")
+		testutils.Equals(t, int64(8), tab.Stats().Cost)
+
+		tab.Insert("c", "cccc")
+		s := tab.Stats()
+		testutils.Assert(t, s.Cost <= 10, "%s: cost %d is over", policy, s.Cost)
+		testutils.Equals(t, 2, s.Size)
+		testutils.Equals(t, uint64(1), s.Evictions)
+
+		tab.Insert("c", "cc")
+		testutils.Equals(t, int64(6), tab.Stats().Cost)
+
+		tab.DeleteObject("c")
+		testutils.Equals(t, int64(4), tab.Stats().Cost)
+
+		tab.Insert("d", "dddddddddddd")
+		testutils.Assert(t, tab.Stats().Cost <= 10, "%s: the value over the max cost is evicted", policy)
+
+		tab.DeleteObjects()
+		testutils.Equals(t, int64(0), tab.Stats().Cost)
+	}
+}
+
+func TestTableCostValueMode(t *testing.T) {
+	for mode, cost := range map[cache.ValueMode]int64{
+		cache.ValueModeUnique:       1,
+		cache.ValueModeBag:          2,
+		cache.ValueModeDuplicateBag: 3,
+	} {
+		tab, err := cache.NewTableCache("cost", cache.OptionValueMode(mode))
+		testutils.Ok(t, err)
+
+		tab.Insert("a", 1)
+		tab.Insert("a", 2)
+		tab.Insert("a", 2)
+		testutils.Equals(t, cost, tab.Stats().Cost)
+	}
+}
+
+func TestShardedTableCost(t *testing.T) {
+	tab, err := cache.NewTableCache("cost", cache.OptionShards(4), cache.OptionMaxCost("10"))
+	testutils.Ok(t, err)
+
+	for i := 0; i < 100; i++ {
+		tab.Insert(i, i)
+	}
+	s := tab.Stats()
+	testutils.Assert(t, s.Cost <= 10, "cost %d is over", s.Cost)
+	testutils.Equals(t, int64(s.Size), s.Cost)
+}
+
+func TestShardedTableSmallCost(t *testing.T) {
+	tab, err := cache.NewTableCache("cost", cache.OptionShards(8), cache.OptionMaxCost("3"))
+	testutils.Ok(t, err)
+
+	for i := 0; i < 100; i++ {
+		tab.Insert(i, i)
+	}
+	s := tab.Stats()
+	testutils.Assert(t, s.Cost <= 3, "cost %d is over", s.Cost)
+	testutils.Assert(t, s.Cost > 0, "values should be kept")
+}
@@ -40,4 +40,45 @@ type DataValues struct {
 	Values []interface{}
 	Exists map[interface{}]bool
 	Expire *time.Time
+
+	// total cost of values
+	cost int64
+}
+
+func newDataValues(key interface{}) *DataValues {
+	return &DataValues{Key: key, Exists: make(map[interface{}]bool)}
+}
+
+// insert inserts the value of cost by the value mode
+func (p *DataValues) insert(mode ValueMode, value interface{}, cost int64) {
+	switch mode {
+	case ValueModeBag:
+		if !p.Exists[value] {
+			p.Values = append(p.Values, value)
+			p.Exists[value] = true
+			p.cost += cost
+		}
+	case ValueModeDuplicateBag:
+		p.Values = append(p.Values, value)
+		p.cost += cost
+	case ValueModeUnique:
+		fallthrough
+	default:
+		p.Values = []interface{}{value}
+		p.cost = cost
+	}
+}
+
+// setExpire sets the expired time after expire, NoExpire clears it
+func (p *DataValues) setExpire(expire time.Duration) {
+	if expire > NoExpire {
+		t := time.Now().Add(expire)
+		p.Expire = &t
+		return
+	}
+	p.Expire = nil
+}
+
+func (p *DataValues) isExpired() bool {
+	return p.Expire != nil && p.Expire.UnixNano() < time.Now().UnixNano()
 }
@@ -26,4 +26,10 @@ var (
 	ErrOrderSetMustBeBool    = errors.New("order set must be bool")
 	ErrUnknownTableOption    = errors.New("unknown table option")
 	ErrUnknownTableValueMode = errors.New("unknown table value mode")
+	ErrUnknownTablePolicy    = errors.New("unknown table policy")
+	ErrLoaderNotFound        = errors.New("loader not found")
+	ErrRefreshNotUnique      = errors.New("refreshed values must be in the unique value mode")
+	ErrInvalidTableSize      = errors.New("must provide a positive size, 0 is unlimit")
+	ErrInvalidTableCost      = errors.New("must provide a positive max cost, 0 is unlimit")
+	ErrUnknownSnapshot       = errors.New("unknown snapshot, the codec should unmarshal *Snapshot")
 )
@@ -22,20 +22,59 @@ import (
 	"sync"
 	"time"
 
+	"github.com/iTrellis/common/files"
 	"github.com/iTrellis/common/formats"
 )
 
 type gemCache struct {
 	sync.RWMutex
 
-	tables map[string]TableCache
+	tables  map[string]TableCache
+	options CacheOptions
+	// tables created by the snapshot, they can be created again with options
+	restored map[string]bool
+
+	files        files.FileRepo
+	fileLocker   sync.Mutex
+	stopSnapshot chan struct{}
+	snapshotDone chan struct{}
+	closeOnce    sync.Once
 }
 
 // New return cache manager
 func New() Cache {
-	return &gemCache{
-		tables: make(map[string]TableCache),
+	c, _ := NewCache()
+	return c
+}
+
+// NewCache return cache manager with options,
+// the snapshot file is restored if it exists, then it is saved every interval
+func NewCache(opts ...CacheOptionFunc) (Cache, error) {
+	c := &gemCache{
+		tables:   make(map[string]TableCache),
+		restored: make(map[string]bool),
+	}
+	for _, o := range opts {
+		o(&c.options)
+	}
+	if c.options.Codec == nil {
+		c.options.Codec = NewSnapshotCodec()
+	}
+
+	if c.options.SnapshotFile == "" {
+		return c, nil
+	}
+
+	c.files = files.NewFileRepo()
+	if err := c.loadFile(); err != nil {
+		return nil, err
+	}
+	if c.options.SnapshotInterval > 0 {
+		c.stopSnapshot = make(chan struct{})
+		c.snapshotDone = make(chan struct{})
+		go c.runSnapshot()
 	}
+	return c, nil
 }
 
 func (p *gemCache) All() []string {
@@ -52,6 +91,8 @@ func (p *gemCache) All() []string {
 }
 
 func (p *gemCache) GetTableCache(tab string) (TableCache, bool) {
+	p.RLock()
+	defer p.RUnlock()
 	t, ok := p.tables[tab]
 	return t, ok
 }
@@ -60,15 +101,36 @@ func (p *gemCache) New(tab string, options ...OptionFunc) (err error) {
 	p.Lock()
 	defer p.Unlock()
 
-	tabCache := p.getTable(tab)
-	if tabCache != nil {
+	restored := p.getTable(tab)
+	if restored != nil && !p.restored[tab] {
 		return ErrTableExists
 	}
 
-	if tabCache, err = NewTableCache(tab, options...); err != nil {
+	opts := Options{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	var tabCache TableCache
+	// tables with the loader are loading tables
+	if opts.Loader != nil {
+		tabCache, err = NewLoadingTable(tab, options...)
+	} else {
+		tabCache, err = NewTableCache(tab, options...)
+	}
+	if err != nil {
 		return
 	}
 
+	// the table created by the snapshot is created again with the options, and keeps its values
+	if restored != nil {
+		if st, ok := restored.(snapshotTable); ok {
+			st.snapshot().restore(tabCache)
+		}
+		restored.Close()
+		delete(p.restored, tab)
+	}
+
 	p.tables[tab] = tabCache
 
 	return nil
@@ -81,6 +143,7 @@ func (p *gemCache) Delete(tab string) bool {
 	tabCache := p.getTable(tab)
 	if tabCache != nil {
 		tabCache.DeleteObjects()
+		tabCache.Close()
 		delete(p.tables, tab)
 	}
 	return true
@@ -161,3 +224,28 @@ func (p *gemCache) LookupAll(tab string) (map[interface{}][]interface{}, bool) {
 	}
 	return tabCache.LookupAll()
 }
+
+func (p *gemCache) LookupIndex(tab, index string, indexKey interface{}) ([]interface{}, bool) {
+	tabCache := p.getTable(tab)
+	if tabCache == nil {
+		return nil, false
+	}
+	return tabCache.LookupIndex(index, indexKey)
+}
+
+func (p *gemCache) Range(tab string, fn func(key interface{}, values []interface{}) bool) {
+	tabCache := p.getTable(tab)
+	if tabCache == nil {
+		return
+	}
+	tabCache.Range(fn)
+}
+
+func (p *gemCache) Select(tab string, predicate func(key interface{}, values []interface{}) bool) (
+	map[interface{}][]interface{}, bool) {
+	tabCache := p.getTable(tab)
+	if tabCache == nil {
+		return nil, false
+	}
+	return tabCache.Select(predicate)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+)
+
+var hashSeed = maphash.MakeSeed()
+
+// hashKey returns the hash of key, keys of other types than strings and numbers are hashed by fmt
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return mixHash(uint64(k))
+	case int8:
+		return mixHash(uint64(k))
+	case int16:
+		return mixHash(uint64(k))
+	case int32:
+		return mixHash(uint64(k))
+	case int64:
+		return mixHash(uint64(k))
+	case uint:
+		return mixHash(uint64(k))
+	case uint8:
+		return mixHash(uint64(k))
+	case uint16:
+		return mixHash(uint64(k))
+	case uint32:
+		return mixHash(uint64(k))
+	case uint64:
+		return mixHash(k)
+	case float32:
+		return mixHash(uint64(math.Float32bits(k)))
+	case float64:
+		return mixHash(math.Float64bits(k))
+	}
+	return hashString(fmt.Sprintf("%T:%v", key, key))
+}
+
+func hashString(s string) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString(s)
+	return h.Sum64()
+}
+
+// mixHash spreads the bits of integer keys, splitmix64 finalizer
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
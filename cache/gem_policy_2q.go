@@ -0,0 +1,116 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+// twoQueuePolicy is the full version of 2Q:
+// new keys are in the FIFO queue in, keys evicted from in are remembered by the ghost queue out,
+// keys hit in out are promoted to the LRU queue main
+type twoQueuePolicy struct {
+	size    int
+	inSize  int
+	outSize int
+
+	in   *keyList
+	out  *keyList
+	main *keyList
+}
+
+const (
+	twoQueueInRatio  = 0.25
+	twoQueueOutRatio = 0.5
+)
+
+func new2QPolicy(size int) *twoQueuePolicy {
+	return &twoQueuePolicy{
+		size:    size,
+		inSize:  maxInt(1, int(float64(size)*twoQueueInRatio)),
+		outSize: maxInt(1, int(float64(size)*twoQueueOutRatio)),
+		in:      newKeyList(),
+		out:     newKeyList(),
+		main:    newKeyList(),
+	}
+}
+
+func (p *twoQueuePolicy) add(key interface{}) (evicted []interface{}) {
+	if p.in.has(key) || p.main.has(key) {
+		p.access(key)
+		return nil
+	}
+
+	if p.in.len()+p.main.len() >= p.size {
+		evicted = p.reclaim()
+	}
+
+	if p.out.remove(key) {
+		p.main.pushFront(key)
+		return evicted
+	}
+	p.in.pushFront(key)
+	return evicted
+}
+
+// reclaim evicts the oldest key of in if in is over its size, or the least recently used key of main
+func (p *twoQueuePolicy) reclaim() []interface{} {
+	if p.in.len() > p.inSize || p.main.len() == 0 {
+		k, ok := p.in.removeBack()
+		if !ok {
+			return nil
+		}
+		p.out.pushFront(k)
+		p.trimOut()
+		return []interface{}{k}
+	}
+	k, ok := p.main.removeBack()
+	if !ok {
+		return nil
+	}
+	return []interface{}{k}
+}
+
+// trimOut keeps out in its size, the size is unlimited in a table only with the cost,
+// so out is kept in the number of live keys
+func (p *twoQueuePolicy) trimOut() {
+	limit := minInt(p.outSize, maxInt(1, p.in.len()+p.main.len()))
+	for p.out.len() > limit {
+		p.out.removeBack()
+	}
+}
+
+func (p *twoQueuePolicy) evict() (interface{}, bool) {
+	if keys := p.reclaim(); len(keys) > 0 {
+		return keys[0], true
+	}
+	return nil, false
+}
+
+// access moves the key of main to the front, keys of in stay in the FIFO order
+func (p *twoQueuePolicy) access(key interface{}) {
+	p.main.moveToFront(key)
+}
+
+func (p *twoQueuePolicy) remove(key interface{}) {
+	if !p.in.remove(key) {
+		p.main.remove(key)
+	}
+}
+
+func (p *twoQueuePolicy) reset() {
+	p.in.reset()
+	p.out.reset()
+	p.main.reset()
+}
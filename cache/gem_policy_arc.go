@@ -0,0 +1,160 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+// arcPolicy is the adaptive replacement cache:
+// t1 has keys hit once, t2 has keys hit more, b1 and b2 are the ghosts evicted from them,
+// the target size of t1 grows on hits of b1 and shrinks on hits of b2
+type arcPolicy struct {
+	size   int
+	target int
+
+	t1, t2 *keyList
+	b1, b2 *keyList
+}
+
+func newARCPolicy(size int) *arcPolicy {
+	return &arcPolicy{
+		size: size,
+		t1:   newKeyList(),
+		t2:   newKeyList(),
+		b1:   newKeyList(),
+		b2:   newKeyList(),
+	}
+}
+
+func (p *arcPolicy) add(key interface{}) (evicted []interface{}) {
+	if p.t1.has(key) || p.t2.has(key) {
+		p.access(key)
+		return nil
+	}
+
+	switch {
+	case p.b1.has(key):
+		p.target = minInt(p.size, p.target+maxInt(p.b2.len()/p.b1.len(), 1))
+		evicted = p.replace(false)
+		p.b1.remove(key)
+		p.t2.pushFront(key)
+		return evicted
+	case p.b2.has(key):
+		p.target = maxInt(0, p.target-maxInt(p.b1.len()/p.b2.len(), 1))
+		evicted = p.replace(true)
+		p.b2.remove(key)
+		p.t2.pushFront(key)
+		return evicted
+	}
+
+	if p.t1.len()+p.b1.len() >= p.size {
+		if p.t1.len() < p.size {
+			p.b1.removeBack()
+			evicted = p.replace(false)
+		} else if k, ok := p.t1.removeBack(); ok {
+			evicted = append(evicted, k)
+		}
+	} else if total := p.t1.len() + p.t2.len() + p.b1.len() + p.b2.len(); total >= p.size {
+		if total >= 2*p.size {
+			p.b2.removeBack()
+		}
+		evicted = p.replace(false)
+	}
+	p.t1.pushFront(key)
+	return evicted
+}
+
+// replace evicts a key of t1 or t2 into its ghost if the cache is full
+func (p *arcPolicy) replace(inB2 bool) []interface{} {
+	if p.t1.len()+p.t2.len() < p.size {
+		return nil
+	}
+	if t1 := p.t1.len(); t1 > 0 && (t1 > p.target || (inB2 && t1 == p.target)) {
+		k, _ := p.t1.removeBack()
+		p.b1.pushFront(k)
+		return []interface{}{k}
+	}
+	k, ok := p.t2.removeBack()
+	if !ok {
+		k, _ = p.t1.removeBack()
+		p.b1.pushFront(k)
+		return []interface{}{k}
+	}
+	p.b2.pushFront(k)
+	return []interface{}{k}
+}
+
+func (p *arcPolicy) evict() (interface{}, bool) {
+	if t1 := p.t1.len(); t1 > 0 && (t1 > p.target || p.t2.len() == 0) {
+		k, _ := p.t1.removeBack()
+		p.b1.pushFront(k)
+		p.trimGhosts()
+		return k, true
+	}
+	k, ok := p.t2.removeBack()
+	if ok {
+		p.b2.pushFront(k)
+		p.trimGhosts()
+	}
+	return k, ok
+}
+
+// trimGhosts keeps the ghosts in the size when keys are evicted by the cost,
+// the size is unlimited in a table only with the cost, so the ghosts are kept in the number of live keys
+func (p *arcPolicy) trimGhosts() {
+	limit := minInt(p.size, p.t1.len()+p.t2.len())
+	for p.b1.len() > limit {
+		p.b1.removeBack()
+	}
+	for p.b2.len() > limit {
+		p.b2.removeBack()
+	}
+}
+
+func (p *arcPolicy) access(key interface{}) {
+	if p.t1.remove(key) {
+		p.t2.pushFront(key)
+		return
+	}
+	p.t2.moveToFront(key)
+}
+
+func (p *arcPolicy) remove(key interface{}) {
+	if !p.t1.remove(key) {
+		p.t2.remove(key)
+	}
+}
+
+func (p *arcPolicy) reset() {
+	p.target = 0
+	p.t1.reset()
+	p.t2.reset()
+	p.b1.reset()
+	p.b2.reset()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/list"
+)
+
+// lfuPolicy evicts the least frequently used key,
+// keys are in buckets of their frequencies, the least recently used one of a bucket is evicted first
+type lfuPolicy struct {
+	size    int
+	items   map[interface{}]*lfuEntry
+	buckets *list.List
+}
+
+type lfuBucket struct {
+	freq int
+	keys *list.List
+}
+
+type lfuEntry struct {
+	key    interface{}
+	bucket *list.Element
+	elem   *list.Element
+}
+
+func newLFUPolicy(size int) *lfuPolicy {
+	return &lfuPolicy{
+		size:    size,
+		items:   make(map[interface{}]*lfuEntry),
+		buckets: list.New(),
+	}
+}
+
+func (p *lfuPolicy) add(key interface{}) (evicted []interface{}) {
+	if _, ok := p.items[key]; ok {
+		p.access(key)
+		return nil
+	}
+
+	for len(p.items) >= p.size {
+		victim, ok := p.evict()
+		if !ok {
+			break
+		}
+		evicted = append(evicted, victim)
+	}
+
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	e := &lfuEntry{key: key, bucket: front}
+	e.elem = front.Value.(*lfuBucket).keys.PushFront(e)
+	p.items[key] = e
+	return evicted
+}
+
+func (p *lfuPolicy) evict() (interface{}, bool) {
+	front := p.buckets.Front()
+	if front == nil {
+		return nil, false
+	}
+	victim := front.Value.(*lfuBucket).keys.Back().Value.(*lfuEntry)
+	p.remove(victim.key)
+	return victim.key, true
+}
+
+func (p *lfuPolicy) access(key interface{}) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	cur := e.bucket.Value.(*lfuBucket)
+	next := e.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != cur.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: cur.freq + 1, keys: list.New()}, e.bucket)
+	}
+
+	cur.keys.Remove(e.elem)
+	if cur.keys.Len() == 0 {
+		p.buckets.Remove(e.bucket)
+	}
+	e.bucket = next
+	e.elem = next.Value.(*lfuBucket).keys.PushFront(e)
+}
+
+func (p *lfuPolicy) remove(key interface{}) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	b := e.bucket.Value.(*lfuBucket)
+	b.keys.Remove(e.elem)
+	if b.keys.Len() == 0 {
+		p.buckets.Remove(e.bucket)
+	}
+	delete(p.items, key)
+}
+
+func (p *lfuPolicy) reset() {
+	p.items = make(map[interface{}]*lfuEntry)
+	p.buckets.Init()
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestPolicyGhostsWithCost(t *testing.T) {
+	for _, policy := range []Policy{PolicyARC, Policy2Q} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tab, err := newPolicyTable("ghosts", Options{
+				Policy:  policy,
+				MaxCost: 100,
+				Cost:    func(_, _ interface{}) int64 { return 1 },
+			})
+			testutils.Ok(t, err)
+
+			for i := 0; i < 100000; i++ {
+				tab.Insert(i, i)
+			}
+			testutils.Equals(t, 100, len(tab.items))
+
+			switch p := tab.policy.(type) {
+			case *arcPolicy:
+				testutils.Assert(t, p.b1.len() <= 100, "b1 has %d ghosts", p.b1.len())
+				testutils.Assert(t, p.b2.len() <= 100, "b2 has %d ghosts", p.b2.len())
+			case *twoQueuePolicy:
+				testutils.Assert(t, p.out.len() <= 100, "out has %d ghosts", p.out.len())
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+// tinyLFUPolicy is the window TinyLFU:
+// new keys are in a small LRU window, the keys evicted from the window are candidates of
+// the main segmented LRU, a candidate is admitted if it is more frequent than the victim of main
+type tinyLFUPolicy struct {
+	windowSize    int
+	protectedSize int
+	mainSize      int
+
+	window    *keyList
+	probation *keyList
+	protected *keyList
+
+	sketch *countMinSketch
+}
+
+const (
+	tinyLFUWindowRatio    = 0.01
+	tinyLFUProtectedRatio = 0.8
+	// the width of sketch of the table with unlimit size
+	tinyLFUMaxSketchWidth = 1 << 20
+)
+
+func newTinyLFUPolicy(size int) *tinyLFUPolicy {
+	windowSize := maxInt(1, int(float64(size)*tinyLFUWindowRatio))
+	mainSize := size - windowSize
+	return &tinyLFUPolicy{
+		windowSize:    windowSize,
+		mainSize:      mainSize,
+		protectedSize: int(float64(mainSize) * tinyLFUProtectedRatio),
+		window:        newKeyList(),
+		probation:     newKeyList(),
+		protected:     newKeyList(),
+		sketch:        newCountMinSketch(minInt(size, tinyLFUMaxSketchWidth)),
+	}
+}
+
+func (p *tinyLFUPolicy) add(key interface{}) []interface{} {
+	if p.window.has(key) || p.probation.has(key) || p.protected.has(key) {
+		p.access(key)
+		return nil
+	}
+
+	p.sketch.increment(key)
+	p.window.pushFront(key)
+	if p.window.len() <= p.windowSize {
+		return nil
+	}
+
+	candidate, _ := p.window.removeBack()
+	if p.probation.len()+p.protected.len() < p.mainSize {
+		p.probation.pushFront(candidate)
+		return nil
+	}
+
+	victims := p.probation
+	if victims.len() == 0 {
+		victims = p.protected
+	}
+	victim, ok := victims.back()
+	if !ok || p.sketch.estimate(candidate) <= p.sketch.estimate(victim) {
+		return []interface{}{candidate}
+	}
+	victims.remove(victim)
+	p.probation.pushFront(candidate)
+	return []interface{}{victim}
+}
+
+func (p *tinyLFUPolicy) evict() (interface{}, bool) {
+	for _, l := range []*keyList{p.probation, p.protected, p.window} {
+		if k, ok := l.removeBack(); ok {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func (p *tinyLFUPolicy) access(key interface{}) {
+	p.sketch.increment(key)
+
+	switch {
+	case p.window.moveToFront(key), p.protected.moveToFront(key):
+	case p.probation.remove(key):
+		p.protected.pushFront(key)
+		if p.protected.len() > p.protectedSize {
+			if k, ok := p.protected.removeBack(); ok {
+				p.probation.pushFront(k)
+			}
+		}
+	}
+}
+
+func (p *tinyLFUPolicy) remove(key interface{}) {
+	if !p.window.remove(key) && !p.probation.remove(key) {
+		p.protected.remove(key)
+	}
+}
+
+func (p *tinyLFUPolicy) reset() {
+	p.window.reset()
+	p.probation.reset()
+	p.protected.reset()
+	p.sketch.reset()
+}
+
+const (
+	sketchDepth      = 4
+	sketchMaxCounter = 15
+)
+
+// countMinSketch estimates the frequencies of keys with 4 rows of counters,
+// all counters are halved after width*10 increments to age the history
+type countMinSketch struct {
+	mask      uint64
+	rows      [sketchDepth][]uint8
+	additions int
+	resetAt   int
+}
+
+func newCountMinSketch(size int) *countMinSketch {
+	width := 16
+	for width < size {
+		width <<= 1
+	}
+	s := &countMinSketch{mask: uint64(width - 1), resetAt: width * 10}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (p *countMinSketch) indexes(key interface{}) [sketchDepth]uint64 {
+	h := hashKey(key)
+	h1, h2 := h&0xffffffff, (h>>32)|1
+	var idx [sketchDepth]uint64
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & p.mask
+	}
+	return idx
+}
+
+func (p *countMinSketch) increment(key interface{}) {
+	for i, j := range p.indexes(key) {
+		if p.rows[i][j] < sketchMaxCounter {
+			p.rows[i][j]++
+		}
+	}
+
+	p.additions++
+	if p.additions >= p.resetAt {
+		p.age()
+	}
+}
+
+func (p *countMinSketch) estimate(key interface{}) uint8 {
+	min := uint8(sketchMaxCounter)
+	for i, j := range p.indexes(key) {
+		if p.rows[i][j] < min {
+			min = p.rows[i][j]
+		}
+	}
+	return min
+}
+
+func (p *countMinSketch) age() {
+	p.additions /= 2
+	for i := range p.rows {
+		for j := range p.rows[i] {
+			p.rows[i][j] >>= 1
+		}
+	}
+}
+
+func (p *countMinSketch) reset() {
+	p.additions = 0
+	for i := range p.rows {
+		for j := range p.rows[i] {
+			p.rows[i][j] = 0
+		}
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iTrellis/common/errors"
+)
+
+// LoaderFunc loads the value of key when it is not in the table
+type LoaderFunc func(key interface{}) (interface{}, error)
+
+// LoadingTable is a table loading the missing values by the loader,
+// concurrent loads of a key are deduplicated, the errors of loader are cached for NegativeTTL
+type LoadingTable struct {
+	TableCache
+
+	loader       LoaderFunc
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	refreshAhead time.Duration
+	valueMode    ValueMode
+
+	locker  sync.Mutex
+	calls   map[interface{}]*loadCall
+	errs    map[interface{}]*loadError
+	expires map[interface{}]time.Time
+	// errsSweep is the number of errors to sweep the expired ones
+	errsSweep int
+
+	stats *statsCounter
+}
+
+// minErrorsSweep is the least number of errors to sweep the expired ones
+const minErrorsSweep = 64
+
+type loadCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+type loadError struct {
+	err    error
+	expire time.Time
+}
+
+// OptionLoader set the default loader of the loading table
+func OptionLoader(loader LoaderFunc) OptionFunc {
+	return func(t *Options) {
+		t.Loader = loader
+	}
+}
+
+// OptionLoadTTL set the expire time of loaded values, NoExpire is never expired
+func OptionLoadTTL(ttl time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.LoadTTL = ttl
+	}
+}
+
+// OptionNegativeTTL cache the errors of loader for ttl
+func OptionNegativeTTL(ttl time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.NegativeTTL = ttl
+	}
+}
+
+// OptionRefreshAhead reload the value in background when it is got in ahead of its expiry,
+// the stale value is returned until the new one is loaded;
+// the value mode must be unique, or the reloaded values are appended to the stale ones
+func OptionRefreshAhead(ahead time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.RefreshAhead = ahead
+	}
+}
+
+// NewLoadingTable constructs a loading table with the options of NewTableCache
+func NewLoadingTable(name string, opts ...OptionFunc) (*LoadingTable, error) {
+	options := Options{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.RefreshAhead > 0 && options.ValueMode != ValueModeUnique {
+		return nil, ErrRefreshNotUnique
+	}
+
+	t := &LoadingTable{
+		loader:       options.Loader,
+		ttl:          options.LoadTTL,
+		negativeTTL:  options.NegativeTTL,
+		refreshAhead: options.RefreshAhead,
+		valueMode:    options.ValueMode,
+		calls:        make(map[interface{}]*loadCall),
+		errs:         make(map[interface{}]*loadError),
+		expires:      make(map[interface{}]time.Time),
+		errsSweep:    minErrorsSweep,
+		stats:        newLoadStatsCounter(),
+	}
+
+	// forget the expiry and the error of removed keys
+	evict := options.Evict
+	options.Evict = func(key, value interface{}) {
+		t.locker.Lock()
+		delete(t.expires, key)
+		delete(t.errs, key)
+		t.locker.Unlock()
+		if evict != nil {
+			evict(key, value)
+		}
+	}
+
+	tc, err := NewTableCache(name, func(o *Options) { *o = options })
+	if err != nil {
+		return nil, err
+	}
+	t.TableCache = tc
+	return t, nil
+}
+
+// Get returns the value of key, it is loaded by the default loader if it is missing
+func (p *LoadingTable) Get(key interface{}) (interface{}, error) {
+	if p.loader == nil {
+		return nil, ErrLoaderNotFound
+	}
+	return p.GetOrLoad(key, p.loader)
+}
+
+// GetOrLoad returns the first value of key, it is loaded by the loader if it is missing
+func (p *LoadingTable) GetOrLoad(key interface{}, loader LoaderFunc) (interface{}, error) {
+	if vs, ok := p.Lookup(key); ok && len(vs) > 0 {
+		if p.shouldRefresh(key) {
+			go p.load(key, loader)
+		}
+		return vs[0], nil
+	}
+
+	p.locker.Lock()
+	if e, ok := p.errs[key]; ok {
+		if time.Now().Before(e.expire) {
+			p.locker.Unlock()
+			return nil, e.err
+		}
+		delete(p.errs, key)
+	}
+	p.locker.Unlock()
+
+	return p.load(key, loader)
+}
+
+// Refresh loads the value of key again by the default loader, the value mode must be unique
+func (p *LoadingTable) Refresh(key interface{}) error {
+	if p.loader == nil {
+		return ErrLoaderNotFound
+	}
+	if p.valueMode != ValueModeUnique {
+		return ErrRefreshNotUnique
+	}
+	_, err := p.load(key, p.loader)
+	return err
+}
+
+// Stats returns the counters of the table and the loads.
+func (p *LoadingTable) Stats() Stats {
+	stats := p.TableCache.Stats()
+	loads := p.stats.stats(0, 0)
+	stats.Loads, stats.LoadErrors, stats.LoadTime = loads.Loads, loads.LoadErrors, loads.LoadTime
+	stats.LoadBuckets, stats.LoadDurations, stats.LoadCount = loads.LoadBuckets, loads.LoadDurations, loads.LoadCount
+	return stats
+}
+
+func (p *LoadingTable) snapshot() *TableSnapshot {
+	st, ok := p.TableCache.(snapshotTable)
+	if !ok {
+		return &TableSnapshot{}
+	}
+	return st.snapshot()
+}
+
+// shouldRefresh returns true if the value of key will be expired in refresh ahead
+func (p *LoadingTable) shouldRefresh(key interface{}) bool {
+	if p.refreshAhead <= 0 || p.ttl <= NoExpire {
+		return false
+	}
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if _, loading := p.calls[key]; loading {
+		return false
+	}
+	expire, ok := p.expires[key]
+	return ok && time.Until(expire) < p.refreshAhead
+}
+
+// load calls the loader once for the concurrent loads of key
+func (p *LoadingTable) load(key interface{}, loader LoaderFunc) (interface{}, error) {
+	p.locker.Lock()
+	if c, ok := p.calls[key]; ok {
+		p.locker.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+	c := &loadCall{done: make(chan struct{})}
+	p.calls[key] = c
+	p.locker.Unlock()
+
+	// the waiters of the call must be released even if the loader panics
+	defer p.finishLoad(key, c)
+
+	start := time.Now()
+	c.value, c.err = callLoader(key, loader)
+	p.stats.load(time.Since(start), c.err)
+	if c.err != nil {
+		return c.value, c.err
+	}
+
+	// the expiry is set before inserting, so it is forgotten if the value is evicted at once
+	p.locker.Lock()
+	delete(p.errs, key)
+	if p.ttl > NoExpire {
+		p.expires[key] = time.Now().Add(p.ttl)
+	}
+	p.locker.Unlock()
+
+	if !p.InsertExpire(key, c.value, p.ttl) {
+		p.locker.Lock()
+		delete(p.expires, key)
+		p.locker.Unlock()
+	}
+	return c.value, c.err
+}
+
+// finishLoad caches the error of the call and releases its waiters
+func (p *LoadingTable) finishLoad(key interface{}, c *loadCall) {
+	p.locker.Lock()
+	if c.err != nil && p.negativeTTL > 0 {
+		p.errs[key] = &loadError{err: c.err, expire: time.Now().Add(p.negativeTTL)}
+		p.removeExpiredErrors()
+	}
+	delete(p.calls, key)
+	p.locker.Unlock()
+
+	close(c.done)
+}
+
+// removeExpiredErrors forgets the expired errors of keys which are never got again,
+// they are swept when the errors are doubled since the last sweeping
+func (p *LoadingTable) removeExpiredErrors() {
+	if len(p.errs) < p.errsSweep {
+		return
+	}
+	now := time.Now()
+	for key, e := range p.errs {
+		if !now.Before(e.expire) {
+			delete(p.errs, key)
+		}
+	}
+	p.errsSweep = len(p.errs) * 2
+	if p.errsSweep < minErrorsSweep {
+		p.errsSweep = minErrorsSweep
+	}
+}
+
+// callLoader returns the panic of loader as an error
+func callLoader(key interface{}, loader LoaderFunc) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, errors.Newf("loader panicked: %v", r)
+		}
+	}()
+	return loader(key)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestLoadingTableForgetsKeys(t *testing.T) {
+	errLoad := errors.New("load failed")
+	tab, err := NewLoadingTable("forget",
+		OptionKeySize(10),
+		OptionLoadTTL(time.Hour),
+		OptionNegativeTTL(time.Millisecond))
+	testutils.Ok(t, err)
+
+	failed := func(key interface{}) (interface{}, error) { return nil, errLoad }
+	for i := 0; i < 1000; i++ {
+		_, err = tab.GetOrLoad(i, failed)
+		testutils.ErrorEqual(t, errLoad, err)
+		if i%100 == 0 {
+			time.Sleep(time.Millisecond * 2)
+		}
+	}
+	testutils.Assert(t, len(tab.errs) < 1000, "expired errors should be swept, got %d", len(tab.errs))
+
+	loaded := func(key interface{}) (interface{}, error) { return key, nil }
+	for i := 0; i < 1000; i++ {
+		_, err = tab.GetOrLoad(i, loaded)
+		testutils.Ok(t, err)
+	}
+	testutils.Equals(t, 10, len(tab.expires))
+
+	// the value over the max cost is evicted at once
+	tab, err = NewLoadingTable("oversize",
+		OptionMaxCost("1"),
+		OptionCost(func(_, _ interface{}) int64 { return 2 }),
+		OptionLoadTTL(time.Hour))
+	testutils.Ok(t, err)
+	_, err = tab.GetOrLoad("a", loaded)
+	testutils.Ok(t, err)
+	testutils.Assert(t, !tab.Member("a"), "a should be evicted")
+	testutils.Equals(t, 0, len(tab.expires))
+}
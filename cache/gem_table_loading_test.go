@@ -0,0 +1,174 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestLoadingTable(t *testing.T) {
+	var loads int32
+	release := make(chan struct{})
+	tab, err := cache.NewLoadingTable("loading", cache.OptionLoader(func(key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return key.(string) + "-value", nil
+	}))
+	testutils.Ok(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := tab.Get("a")
+			testutils.Ok(t, err)
+			testutils.Equals(t, "a-value", v)
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	close(release)
+	wg.Wait()
+
+	testutils.Equals(t, int32(1), atomic.LoadInt32(&loads))
+	vs, ok := tab.Lookup("a")
+	testutils.Assert(t, ok, "a should be cached")
+	testutils.Equals(t, []interface{}{"a-value"}, vs)
+}
+
+func TestLoadingTableNegativeTTL(t *testing.T) {
+	errLoad := errors.New("load failed")
+	var loads int32
+	tab, err := cache.NewLoadingTable("negative", cache.OptionNegativeTTL(time.Millisecond*20))
+	testutils.Ok(t, err)
+
+	_, err = tab.Get("a")
+	testutils.ErrorEqual(t, cache.ErrLoaderNotFound, err)
+
+	loader := func(key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, errLoad
+	}
+	for i := 0; i < 3; i++ {
+		_, err = tab.GetOrLoad("a", loader)
+		testutils.ErrorEqual(t, errLoad, err)
+	}
+	testutils.Equals(t, int32(1), atomic.LoadInt32(&loads))
+	testutils.Assert(t, !tab.Member("a"), "errors should not be values")
+
+	time.Sleep(time.Millisecond * 30)
+	_, err = tab.GetOrLoad("a", loader)
+	testutils.ErrorEqual(t, errLoad, err)
+	testutils.Equals(t, int32(2), atomic.LoadInt32(&loads))
+}
+
+func TestLoadingTableRefreshAhead(t *testing.T) {
+	var version int32
+	refreshed := make(chan struct{}, 1)
+	c := cache.New()
+	err := c.New("refresh",
+		cache.OptionLoader(func(key interface{}) (interface{}, error) {
+			v := atomic.AddInt32(&version, 1)
+			if v > 1 {
+				refreshed <- struct{}{}
+			}
+			return v, nil
+		}),
+		cache.OptionLoadTTL(time.Millisecond*100),
+		cache.OptionRefreshAhead(time.Millisecond*80))
+	testutils.Ok(t, err)
+
+	tc, ok := c.GetTableCache("refresh")
+	testutils.Assert(t, ok, "refresh should exist")
+	tab := tc.(*cache.LoadingTable)
+
+	v, err := tab.Get("a")
+	testutils.Ok(t, err)
+	testutils.Equals(t, int32(1), v)
+
+	time.Sleep(time.Millisecond * 30)
+	// the stale value is returned, and refreshed in background
+	v, err = tab.Get("a")
+	testutils.Ok(t, err)
+	testutils.Equals(t, int32(1), v)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("a was not refreshed")
+	}
+	// the loader returned, wait for the insertion
+	for i := 0; i < 100; i++ {
+		if v, _ = tab.Get("a"); v == int32(2) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	testutils.Equals(t, int32(2), v)
+}
+
+func TestLoadingTableRefreshBag(t *testing.T) {
+	loader := cache.OptionLoader(func(key interface{}) (interface{}, error) {
+		return "value", nil
+	})
+	for _, mode := range []cache.ValueMode{cache.ValueModeBag, cache.ValueModeDuplicateBag} {
+		_, err := cache.NewLoadingTable("bag", loader, cache.OptionValueMode(mode),
+			cache.OptionLoadTTL(time.Second), cache.OptionRefreshAhead(time.Millisecond*100))
+		testutils.ErrorEqual(t, cache.ErrRefreshNotUnique, err)
+
+		tab, err := cache.NewLoadingTable("bag", loader, cache.OptionValueMode(mode))
+		testutils.Ok(t, err)
+		testutils.ErrorEqual(t, cache.ErrRefreshNotUnique, tab.Refresh("a"))
+		_, ok := tab.Members()
+		testutils.Assert(t, !ok, "a should not be loaded")
+	}
+}
+
+func TestLoadingTablePanic(t *testing.T) {
+	var loads int32
+	tab, err := cache.NewLoadingTable("panic", cache.OptionLoader(func(key interface{}) (interface{}, error) {
+		if atomic.AddInt32(&loads, 1) == 1 {
+			panic("boom")
+		}
+		return "value", nil
+	}))
+	testutils.Ok(t, err)
+
+	_, err = tab.Get("a")
+	testutils.NotOk(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := tab.Get("a")
+		testutils.Ok(t, err)
+		testutils.Equals(t, "value", v)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("load should not be blocked by the panicked one")
+	}
+}
@@ -19,7 +19,6 @@ package cache
 
 import (
 	"container/list"
-	"errors"
 	"sync"
 	"time"
 )
@@ -30,11 +29,19 @@ type LRU struct {
 
 	locker    sync.RWMutex
 	size      int
+	maxCost   int64
+	cost      int64
 	evictList *list.List
 	items     map[interface{}]*list.Element
-	onEvict   EvictCallback
+	options   Options
+	indexes   *tableIndexes
 
 	valueMode ValueMode
+
+	stats *statsCounter
+
+	stopJanitor chan struct{}
+	closeOnce   sync.Once
 }
 
 // NewTableCache constructs a fixed size cache.
@@ -44,6 +51,14 @@ func NewTableCache(name string, opts ...OptionFunc) (TableCache, error) {
 		o(&options)
 	}
 
+	if options.Shards > 1 {
+		return newShardedTable(name, options)
+	}
+
+	if options.Policy != PolicyLRU {
+		return newPolicyTable(name, options)
+	}
+
 	lru, err := NewLRU(name, options)
 	if err != nil {
 		return nil, err
@@ -54,27 +69,68 @@ func NewTableCache(name string, opts ...OptionFunc) (TableCache, error) {
 
 // NewLRU constructs an LRU of the given options
 func NewLRU(name string, opts Options) (*LRU, error) {
-	if opts.Size < 0 {
-		return nil, errors.New("must provide a positive size, 0 is unlimit")
+	if err := opts.check(); err != nil {
+		return nil, err
 	}
 	c := &LRU{
 		name:      name,
 		size:      opts.Size,
+		maxCost:   opts.MaxCost,
 		evictList: list.New(),
 		items:     make(map[interface{}]*list.Element),
-		onEvict:   opts.Evict,
+		options:   opts,
+		indexes:   newTableIndexes(opts.Indexes),
 		valueMode: opts.ValueMode,
+		stats:     &statsCounter{},
+	}
+
+	if opts.ExpireInterval > 0 {
+		c.stopJanitor = make(chan struct{})
+		go runJanitor(opts.ExpireInterval, c.stopJanitor, c.RemoveExpired)
 	}
 	return c, nil
 }
 
+// Close stops the background janitor of the cache.
+func (p *LRU) Close() {
+	p.closeOnce.Do(func() {
+		if p.stopJanitor != nil {
+			close(p.stopJanitor)
+		}
+	})
+}
+
+// RemoveExpired removes all the expired items from the cache.
+func (p *LRU) RemoveExpired() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for _, e := range p.items {
+		if _, expired := p.isElementExpired(e); expired {
+			p.removeElement(e, EvictReasonExpired)
+		}
+	}
+}
+
+// removeExpired removes the item of key if it is expired.
+func (p *LRU) removeExpired(key interface{}) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if e, ok := p.items[key]; ok {
+		if _, expired := p.isElementExpired(e); expired {
+			p.removeElement(e, EvictReasonExpired)
+		}
+	}
+}
+
 // DeleteObject deletes the provided key from the cache, returning if the
 // key was contained.
 func (p *LRU) DeleteObject(key interface{}) (present bool) {
 	p.locker.Lock()
 	defer p.locker.Unlock()
 	if ent, ok := p.items[key]; ok {
-		p.removeElement(ent)
+		p.removeElement(ent, EvictReasonDeleted)
 		return true
 	}
 	return false
@@ -86,12 +142,13 @@ func (p *LRU) DeleteObjects() {
 	defer p.locker.Unlock()
 
 	for k, v := range p.items {
-		if p.onEvict != nil {
-			p.onEvict(k, v.Value.(*DataValues).Values)
-		}
+		p.stats.removed(EvictReasonDeleted)
+		p.options.callback(k, v.Value.(*DataValues).Values, EvictReasonDeleted)
 		delete(p.items, k)
 	}
 	p.evictList.Init()
+	p.indexes.reset()
+	p.cost = 0
 }
 
 // InsertExpire insert a value to the cache. Returns true if insert kv successful.
@@ -99,38 +156,33 @@ func (p *LRU) InsertExpire(key, value interface{}, expire time.Duration) bool {
 	p.locker.Lock()
 	defer p.locker.Unlock()
 
+	p.stats.insert()
+
 	var dv *DataValues
 
 	// Check for existing item
 	entry, ok := p.items[key]
 	if ok {
-		if _, ok := p.isElementExpired(entry); ok {
-			dv = &DataValues{Key: key, Exists: make(map[interface{}]bool)}
+		if _, expired := p.isElementExpired(entry); expired {
+			p.removeElement(entry, EvictReasonExpired)
+			entry, ok = nil, false
+			dv = newDataValues(key)
 		} else {
 			dv = entry.Value.(*DataValues)
 		}
 	} else {
-		dv = &DataValues{Key: key, Exists: make(map[interface{}]bool)}
+		dv = newDataValues(key)
 	}
 
-	switch p.valueMode {
-	case ValueModeBag:
-		if !dv.Exists[value] {
-			dv.Values = append(dv.Values, value)
-			dv.Exists[value] = true
-		}
-	case ValueModeDuplicateBag:
-		dv.Values = append(dv.Values, value)
-	case ValueModeUnique:
-		fallthrough
-	default:
-		dv.Values = []interface{}{value}
-	}
+	cost := dv.cost
+	p.indexes.remove(key, dv.Values)
+	dv.insert(p.valueMode, value, p.options.cost(key, value))
+	p.indexes.add(key, dv.Values)
+	p.cost += dv.cost - cost
 
 	// set expired time
 	if expire > NoExpire {
-		t := time.Now().Add(expire)
-		dv.Expire = &t
+		dv.setExpire(expire)
 	}
 
 	if ok {
@@ -142,10 +194,11 @@ func (p *LRU) InsertExpire(key, value interface{}, expire time.Duration) bool {
 
 	p.items[key] = entry
 
-	evict := p.size > 0 && p.evictList.Len() > p.size
-	// Verify size not exceeded
-	if evict {
-		p.removeOldest()
+	// Verify size and cost not exceeded
+	for (p.size > 0 && p.evictList.Len() > p.size) || (p.maxCost > 0 && p.cost > p.maxCost) {
+		if _, _, ok := p.removeOldest(EvictReasonCapacity); !ok {
+			break
+		}
 	}
 	return true
 }
@@ -160,21 +213,32 @@ func (p *LRU) Lookup(key interface{}) ([]interface{}, bool) {
 	p.locker.RLock()
 	entry, ok := p.items[key]
 	if ok {
-		values, ok := p.isElementExpired(entry)
+		values, expired := p.isElementExpired(entry)
 		p.locker.RUnlock()
-		if ok {
-			go p.DeleteObject(entry)
+		if expired {
+			p.removeExpired(key)
+			p.stats.miss()
 			return nil, false
 		}
+		p.stats.hit()
 		return values, true
 	}
 	p.locker.RUnlock()
+	p.stats.miss()
 	return nil, false
 }
 
+// Stats returns the counters of the cache.
+func (p *LRU) Stats() Stats {
+	p.locker.RLock()
+	size, cost := len(p.items), p.cost
+	p.locker.RUnlock()
+	return p.stats.stats(size, cost)
+}
+
 func (p *LRU) isElementExpired(e *list.Element) ([]interface{}, bool) {
 	dv := e.Value.(*DataValues)
-	if dv.Expire != nil && dv.Expire.UnixNano() < time.Now().UnixNano() {
+	if dv.isExpired() {
 		return nil, true
 	}
 	return dv.Values, false
@@ -184,8 +248,8 @@ func (p *LRU) isElementExpired(e *list.Element) ([]interface{}, bool) {
 func (p *LRU) LookupAll() (items map[interface{}][]interface{}, ok bool) {
 	p.locker.RLock()
 	for k, v := range p.items {
-		values, ok := p.isElementExpired(v)
-		if ok {
+		values, expired := p.isElementExpired(v)
+		if expired {
 			continue
 		}
 
@@ -200,6 +264,39 @@ func (p *LRU) LookupAll() (items map[interface{}][]interface{}, ok bool) {
 	return
 }
 
+// LookupIndex Look up keys with the index key in the named index.
+func (p *LRU) LookupIndex(index string, indexKey interface{}) (keys []interface{}, ok bool) {
+	p.locker.RLock()
+	p.indexes.lookup(index, indexKey, func(key interface{}) {
+		if _, expired := p.isElementExpired(p.items[key]); !expired {
+			keys = append(keys, key)
+			ok = true
+		}
+	})
+	p.locker.RUnlock()
+	return
+}
+
+// Range calls fn with every key-values which is not expired until fn returns false.
+func (p *LRU) Range(fn func(key interface{}, values []interface{}) bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	for k, v := range p.items {
+		values, expired := p.isElementExpired(v)
+		if expired {
+			continue
+		}
+		if !fn(k, values) {
+			return
+		}
+	}
+}
+
+// Select Look up key-values matched by the predicate.
+func (p *LRU) Select(predicate func(key interface{}, values []interface{}) bool) (map[interface{}][]interface{}, bool) {
+	return selectRange(p, predicate)
+}
+
 // Member Returns true if one or more elements in the table has key: Key, otherwise false.
 func (p *LRU) Member(key interface{}) bool {
 	p.locker.RLock()
@@ -209,8 +306,11 @@ func (p *LRU) Member(key interface{}) bool {
 		return false
 	}
 
-	_, ok = p.isElementExpired(entry)
-	return !ok
+	if _, expired := p.isElementExpired(entry); expired {
+		p.removeExpired(key)
+		return false
+	}
+	return true
 }
 
 // Members Retruns all keys in the table Tab.
@@ -233,16 +333,15 @@ func (p *LRU) SetExpire(key interface{}, expire time.Duration) bool {
 	defer p.locker.Unlock()
 	entry, ok := p.items[key]
 	if !ok {
-
 		return false
 	}
-	ent := entry.Value.(*DataValues)
-
-	expiredTime := time.Now().Add(expire)
-	ent.Expire = &expiredTime
+	if _, expired := p.isElementExpired(entry); expired {
+		p.removeElement(entry, EvictReasonExpired)
+		return false
+	}
+	entry.Value.(*DataValues).setExpire(expire)
 
-	entry = p.evictList.PushFront(ent)
-	p.items[key] = entry
+	p.evictList.MoveToFront(entry)
 
 	return true
 }
@@ -251,25 +350,39 @@ func (p *LRU) SetExpire(key interface{}, expire time.Duration) bool {
 func (p *LRU) RemoveOldest() (key, value interface{}, ok bool) {
 	p.locker.Lock()
 	defer p.locker.Unlock()
-	return p.removeOldest()
+	return p.removeOldest(EvictReasonCapacity)
 }
 
 // removeOldest removes the oldest item from the cache.
-func (p *LRU) removeOldest() (key, value interface{}, ok bool) {
+func (p *LRU) removeOldest(reason EvictReason) (key, value interface{}, ok bool) {
 	ent := p.evictList.Back()
 	if ent != nil {
-		p.removeElement(ent)
+		p.removeElement(ent, reason)
 		kv := ent.Value.(*DataValues)
 		return kv.Key, kv.Values, true
 	}
 	return nil, nil, false
 }
 
-func (p *LRU) removeElement(e *list.Element) {
+func (p *LRU) removeElement(e *list.Element, reason EvictReason) {
 	p.evictList.Remove(e)
 	kv := e.Value.(*DataValues)
 	delete(p.items, kv.Key)
-	if p.onEvict != nil {
-		p.onEvict(kv.Key, kv.Values)
+	p.indexes.remove(kv.Key, kv.Values)
+	p.cost -= kv.cost
+	p.stats.removed(reason)
+	p.options.callback(kv.Key, kv.Values, reason)
+}
+
+func (p *LRU) snapshot() *TableSnapshot {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	s := &TableSnapshot{Name: p.name, ValueMode: p.valueMode}
+	for e := p.evictList.Back(); e != nil; e = e.Prev() {
+		if entry, ok := e.Value.(*DataValues).snapshot(); ok {
+			s.Entries = append(s.Entries, entry)
+		}
 	}
+	return s
 }
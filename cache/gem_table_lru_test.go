@@ -0,0 +1,99 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+type evictions struct {
+	sync.Mutex
+	keys map[string][]interface{}
+}
+
+func (p *evictions) callback(reason string) cache.EvictCallback {
+	return func(key, _ interface{}) {
+		p.Lock()
+		p.keys[reason] = append(p.keys[reason], key)
+		p.Unlock()
+	}
+}
+
+func (p *evictions) get(reason string) []interface{} {
+	p.Lock()
+	defer p.Unlock()
+	return p.keys[reason]
+}
+
+func TestLRUCallbacks(t *testing.T) {
+	e := &evictions{keys: make(map[string][]interface{})}
+	tab, err := cache.NewTableCache("callbacks",
+		cache.OptionKeySize(2),
+		cache.OptionEvict(e.callback("all")),
+		cache.OptionOnCapacity(e.callback("capacity")),
+		cache.OptionOnExpire(e.callback("expired")),
+		cache.OptionOnDelete(e.callback("deleted")))
+	testutils.Ok(t, err)
+	defer tab.Close()
+
+	tab.Insert("a", 1)
+	tab.Insert("b", 2)
+	tab.Insert("c", 3)
+	testutils.Equals(t, []interface{}{"a"}, e.get("capacity"))
+
+	tab.DeleteObject("b")
+	testutils.Equals(t, []interface{}{"b"}, e.get("deleted"))
+
+	tab.SetExpire("c", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	_, ok := tab.Lookup("c")
+	testutils.Assert(t, !ok, "c should be expired")
+	testutils.Equals(t, []interface{}{"c"}, e.get("expired"))
+
+	testutils.Equals(t, []interface{}{"a", "b", "c"}, e.get("all"))
+}
+
+func TestLRUJanitor(t *testing.T) {
+	expired := make(chan interface{}, 1)
+	tab, err := cache.NewTableCache("janitor",
+		cache.OptionExpireInterval(time.Millisecond*10),
+		cache.OptionOnExpire(func(key, _ interface{}) { expired <- key }))
+	testutils.Ok(t, err)
+	defer tab.Close()
+
+	tab.InsertExpire("a", 1, time.Millisecond)
+	tab.Insert("b", 2)
+
+	select {
+	case key := <-expired:
+		testutils.Equals(t, "a", key)
+	case <-time.After(time.Second):
+		t.Fatal("a was not swept")
+	}
+
+	keys, _ := tab.Members()
+	testutils.Equals(t, []interface{}{"b"}, keys)
+
+	// close twice is safe
+	tab.Close()
+}
@@ -0,0 +1,458 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy define the eviction policy of the table when it is full
+type Policy int
+
+// Policy
+const (
+	// least recently used
+	PolicyLRU Policy = iota
+	// least frequently used, the least recently used one of the same frequency
+	PolicyLFU
+	// adaptive replacement cache, balances recency and frequency
+	PolicyARC
+	// two queues: keys hit once are in a FIFO queue, keys hit again are promoted to a LRU queue
+	Policy2Q
+	// window TinyLFU: a LRU window, and a segmented LRU admitted by the frequency sketch
+	PolicyTinyLFU
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "lru"
+	case PolicyLFU:
+		return "lfu"
+	case PolicyARC:
+		return "arc"
+	case Policy2Q:
+		return "2q"
+	case PolicyTinyLFU:
+		return "tinylfu"
+	}
+	return "unknown"
+}
+
+// evictPolicy tracks keys of a table and decides which keys are evicted
+type evictPolicy interface {
+	// add records the new key, returns the keys evicted to keep the capacity
+	add(key interface{}) []interface{}
+	// access records the hit of the key
+	access(key interface{})
+	// evict forgets and returns the next evicted key, it is used when the cost is over
+	evict() (interface{}, bool)
+	// remove forgets the deleted or expired key
+	remove(key interface{})
+	// reset forgets all keys
+	reset()
+}
+
+func newEvictPolicy(policy Policy, size int) (evictPolicy, error) {
+	switch policy {
+	case PolicyLFU:
+		return newLFUPolicy(size), nil
+	case PolicyARC:
+		return newARCPolicy(size), nil
+	case Policy2Q:
+		return new2QPolicy(size), nil
+	case PolicyTinyLFU:
+		return newTinyLFUPolicy(size), nil
+	}
+	return nil, ErrUnknownTablePolicy
+}
+
+// policyTable implements TableCache with the eviction policy
+type policyTable struct {
+	name string
+
+	locker  sync.Mutex
+	size    int
+	maxCost int64
+	cost    int64
+	items   map[interface{}]*DataValues
+	policy  evictPolicy
+	options Options
+	indexes *tableIndexes
+	stats   *statsCounter
+
+	stopJanitor chan struct{}
+	closeOnce   sync.Once
+}
+
+func newPolicyTable(name string, opts Options) (*policyTable, error) {
+	if err := opts.check(); err != nil {
+		return nil, err
+	}
+	// the number of keys is unlimit, the policy is used to evict keys when the cost is over
+	size := opts.Size
+	if size == 0 {
+		size = math.MaxInt32
+	}
+	policy, err := newEvictPolicy(opts.Policy, size)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &policyTable{
+		name:    name,
+		size:    opts.Size,
+		maxCost: opts.MaxCost,
+		items:   make(map[interface{}]*DataValues),
+		policy:  policy,
+		options: opts,
+		indexes: newTableIndexes(opts.Indexes),
+		stats:   &statsCounter{},
+	}
+
+	if opts.ExpireInterval > 0 {
+		t.stopJanitor = make(chan struct{})
+		go runJanitor(opts.ExpireInterval, t.stopJanitor, t.removeExpired)
+	}
+	return t, nil
+}
+
+func (p *policyTable) Insert(key, value interface{}) bool {
+	return p.InsertExpire(key, value, NoExpire)
+}
+
+func (p *policyTable) InsertExpire(key, value interface{}, expire time.Duration) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	p.stats.insert()
+
+	dv, ok := p.items[key]
+	if ok && dv.isExpired() {
+		p.removeKey(key, EvictReasonExpired)
+		ok = false
+	}
+	if !ok {
+		dv = newDataValues(key)
+		p.items[key] = dv
+	}
+
+	cost := dv.cost
+	p.indexes.remove(key, dv.Values)
+	dv.insert(p.options.ValueMode, value, p.options.cost(key, value))
+	p.indexes.add(key, dv.Values)
+	p.cost += dv.cost - cost
+	if expire > NoExpire {
+		dv.setExpire(expire)
+	}
+
+	if ok {
+		p.touch(key)
+	} else if p.limited() {
+		for _, k := range p.policy.add(key) {
+			p.evictKey(k, EvictReasonCapacity)
+		}
+	}
+
+	for p.maxCost > 0 && p.cost > p.maxCost {
+		k, ok := p.policy.evict()
+		if !ok {
+			break
+		}
+		p.evictKey(k, EvictReasonCapacity)
+	}
+	return true
+}
+
+func (p *policyTable) DeleteObject(key interface{}) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if _, ok := p.items[key]; !ok {
+		return false
+	}
+	p.removeKey(key, EvictReasonDeleted)
+	return true
+}
+
+func (p *policyTable) DeleteObjects() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, dv := range p.items {
+		p.stats.removed(EvictReasonDeleted)
+		p.options.callback(k, dv.Values, EvictReasonDeleted)
+		delete(p.items, k)
+	}
+	p.policy.reset()
+	p.indexes.reset()
+	p.cost = 0
+}
+
+func (p *policyTable) Member(key interface{}) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	_, ok := p.lookup(key)
+	return ok
+}
+
+func (p *policyTable) Members() (keys []interface{}, ok bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, dv := range p.items {
+		if dv.isExpired() {
+			continue
+		}
+		keys = append(keys, k)
+		ok = true
+	}
+	return
+}
+
+func (p *policyTable) Lookup(key interface{}) ([]interface{}, bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	values, ok := p.lookup(key)
+	if ok {
+		p.stats.hit()
+	} else {
+		p.stats.miss()
+	}
+	return values, ok
+}
+
+func (p *policyTable) Stats() Stats {
+	p.locker.Lock()
+	size, cost := len(p.items), p.cost
+	p.locker.Unlock()
+	return p.stats.stats(size, cost)
+}
+
+func (p *policyTable) lookup(key interface{}) ([]interface{}, bool) {
+	dv, ok := p.items[key]
+	if !ok {
+		return nil, false
+	}
+	if dv.isExpired() {
+		p.removeKey(key, EvictReasonExpired)
+		return nil, false
+	}
+	p.touch(key)
+	return dv.Values, true
+}
+
+func (p *policyTable) LookupAll() (items map[interface{}][]interface{}, ok bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, dv := range p.items {
+		if dv.isExpired() {
+			continue
+		}
+		if items == nil {
+			items = make(map[interface{}][]interface{})
+			ok = true
+		}
+		items[k] = dv.Values
+	}
+	return
+}
+
+func (p *policyTable) LookupIndex(index string, indexKey interface{}) (keys []interface{}, ok bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	p.indexes.lookup(index, indexKey, func(key interface{}) {
+		if !p.items[key].isExpired() {
+			keys = append(keys, key)
+			ok = true
+		}
+	})
+	return
+}
+
+func (p *policyTable) Range(fn func(key interface{}, values []interface{}) bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, dv := range p.items {
+		if dv.isExpired() {
+			continue
+		}
+		if !fn(k, dv.Values) {
+			return
+		}
+	}
+}
+
+func (p *policyTable) Select(predicate func(key interface{}, values []interface{}) bool) (
+	map[interface{}][]interface{}, bool) {
+	return selectRange(p, predicate)
+}
+
+func (p *policyTable) SetExpire(key interface{}, expire time.Duration) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	dv, ok := p.items[key]
+	if !ok {
+		return false
+	}
+	if dv.isExpired() {
+		p.removeKey(key, EvictReasonExpired)
+		return false
+	}
+	dv.setExpire(expire)
+	p.touch(key)
+	return true
+}
+
+func (p *policyTable) Close() {
+	p.closeOnce.Do(func() {
+		if p.stopJanitor != nil {
+			close(p.stopJanitor)
+		}
+	})
+}
+
+func (p *policyTable) removeExpired() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, dv := range p.items {
+		if dv.isExpired() {
+			p.removeKey(k, EvictReasonExpired)
+		}
+	}
+}
+
+func (p *policyTable) snapshot() *TableSnapshot {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	s := &TableSnapshot{Name: p.name, ValueMode: p.options.ValueMode}
+	for _, dv := range p.items {
+		if entry, ok := dv.snapshot(); ok {
+			s.Entries = append(s.Entries, entry)
+		}
+	}
+	return s
+}
+
+// limited returns true if the size or the cost is limited, or the policy is not used
+func (p *policyTable) limited() bool {
+	return p.size > 0 || p.maxCost > 0
+}
+
+func (p *policyTable) touch(key interface{}) {
+	if p.limited() {
+		p.policy.access(key)
+	}
+}
+
+// removeKey removes the key from the items and the policy
+func (p *policyTable) removeKey(key interface{}, reason EvictReason) {
+	if p.limited() {
+		p.policy.remove(key)
+	}
+	p.evictKey(key, reason)
+}
+
+// evictKey removes the key which has been forgotten by the policy
+func (p *policyTable) evictKey(key interface{}, reason EvictReason) {
+	dv, ok := p.items[key]
+	if !ok {
+		return
+	}
+	delete(p.items, key)
+	p.indexes.remove(key, dv.Values)
+	p.cost -= dv.cost
+	p.stats.removed(reason)
+	p.options.callback(key, dv.Values, reason)
+}
+
+// keyList is a list of keys which can be found by key
+type keyList struct {
+	l *list.List
+	m map[interface{}]*list.Element
+}
+
+func newKeyList() *keyList {
+	return &keyList{l: list.New(), m: make(map[interface{}]*list.Element)}
+}
+
+func (p *keyList) len() int {
+	return p.l.Len()
+}
+
+func (p *keyList) has(key interface{}) bool {
+	_, ok := p.m[key]
+	return ok
+}
+
+func (p *keyList) pushFront(key interface{}) {
+	p.m[key] = p.l.PushFront(key)
+}
+
+func (p *keyList) moveToFront(key interface{}) bool {
+	e, ok := p.m[key]
+	if ok {
+		p.l.MoveToFront(e)
+	}
+	return ok
+}
+
+func (p *keyList) remove(key interface{}) bool {
+	e, ok := p.m[key]
+	if ok {
+		p.l.Remove(e)
+		delete(p.m, key)
+	}
+	return ok
+}
+
+// removeBack removes the last key
+func (p *keyList) removeBack() (interface{}, bool) {
+	e := p.l.Back()
+	if e == nil {
+		return nil, false
+	}
+	p.l.Remove(e)
+	delete(p.m, e.Value)
+	return e.Value, true
+}
+
+// back returns the last key
+func (p *keyList) back() (interface{}, bool) {
+	e := p.l.Back()
+	if e == nil {
+		return nil, false
+	}
+	return e.Value, true
+}
+
+func (p *keyList) reset() {
+	p.l.Init()
+	p.m = make(map[interface{}]*list.Element)
+}
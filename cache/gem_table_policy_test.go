@@ -0,0 +1,159 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+var policies = []cache.Policy{cache.PolicyLRU, cache.PolicyLFU, cache.PolicyARC, cache.Policy2Q, cache.PolicyTinyLFU}
+
+func TestPolicyTables(t *testing.T) {
+	for _, policy := range policies {
+		t.Run(policy.String(), func(t *testing.T) {
+			var evicted, deleted int
+			tab, err := cache.NewTableCache("policy",
+				cache.OptionPolicy(policy),
+				cache.OptionKeySize(100),
+				cache.OptionValueMode(cache.ValueModeBag),
+				cache.OptionOnCapacity(func(_, _ interface{}) { evicted++ }),
+				cache.OptionOnDelete(func(_, _ interface{}) { deleted++ }))
+			testutils.Ok(t, err)
+			defer tab.Close()
+
+			tab.Insert("a", 1)
+			tab.Insert("a", 2)
+			tab.Insert("a", 1)
+			vs, ok := tab.Lookup("a")
+			testutils.Assert(t, ok, "a should exist")
+			testutils.Equals(t, []interface{}{1, 2}, vs)
+
+			tab.InsertExpire("b", 1, time.Millisecond)
+			time.Sleep(time.Millisecond * 2)
+			testutils.Assert(t, !tab.Member("b"), "b should be expired")
+
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 10000; i++ {
+				key := r.Intn(1000)
+				if _, ok := tab.Lookup(key); !ok {
+					tab.Insert(key, key)
+				}
+				if i%100 == 0 {
+					tab.DeleteObject(key)
+				}
+			}
+
+			keys, _ := tab.Members()
+			testutils.Assert(t, len(keys) <= 100, "size %d should not be over 100", len(keys))
+			testutils.Assert(t, evicted > 0, "keys should be evicted")
+			testutils.Assert(t, deleted > 0, "keys should be deleted")
+
+			tab.DeleteObjects()
+			_, ok = tab.Members()
+			testutils.Assert(t, !ok, "table should be empty")
+		})
+	}
+
+	_, err := cache.NewTableCache("unknown", cache.OptionPolicy(cache.Policy(100)))
+	testutils.ErrorEqual(t, cache.ErrUnknownTablePolicy, err)
+}
+
+func TestPolicyLFU(t *testing.T) {
+	tab, err := cache.NewTableCache("lfu", cache.OptionPolicy(cache.PolicyLFU), cache.OptionKeySize(2))
+	testutils.Ok(t, err)
+
+	tab.Insert("a", 1)
+	tab.Insert("b", 2)
+	tab.Lookup("a")
+	tab.Lookup("a")
+	tab.Lookup("b")
+	tab.Insert("c", 3)
+
+	testutils.Assert(t, tab.Member("a"), "a is the most frequent")
+	testutils.Assert(t, !tab.Member("b"), "b should be evicted")
+	testutils.Assert(t, tab.Member("c"), "c is new")
+}
+
+// hot keys should survive a scan of cold keys, which flushes a LRU
+func TestPolicyScanResistance(t *testing.T) {
+	for _, policy := range []cache.Policy{cache.PolicyLFU, cache.PolicyARC, cache.Policy2Q, cache.PolicyTinyLFU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tab, err := cache.NewTableCache("scan", cache.OptionPolicy(policy), cache.OptionKeySize(100))
+			testutils.Ok(t, err)
+
+			cold := 1000
+			for round := 0; round < 20; round++ {
+				for i := 0; i < 10; i++ {
+					if _, ok := tab.Lookup(i); !ok {
+						tab.Insert(i, i)
+					}
+				}
+				for i := 0; i < 50; i++ {
+					tab.Insert(cold, cold)
+					cold++
+				}
+			}
+			// scan
+			for i := 0; i < 500; i++ {
+				tab.Insert(cold, cold)
+				cold++
+			}
+
+			hits := 0
+			for i := 0; i < 10; i++ {
+				if tab.Member(i) {
+					hits++
+				}
+			}
+			testutils.Assert(t, hits >= 8, "%s: %d hot keys should survive the scan", policy, hits)
+		})
+	}
+}
+
+func BenchmarkPolicyZipf(b *testing.B) {
+	const (
+		keys = 100000
+		size = 1000
+	)
+	for _, policy := range policies {
+		b.Run(policy.String(), func(b *testing.B) {
+			tab, err := cache.NewTableCache("zipf", cache.OptionPolicy(policy), cache.OptionKeySize(size))
+			if err != nil {
+				b.Fatal(err)
+			}
+			zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, keys-1)
+
+			hits := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := zipf.Uint64()
+				if _, ok := tab.Lookup(key); ok {
+					hits++
+					continue
+				}
+				tab.Insert(key, key)
+			}
+			b.ReportMetric(float64(hits)*100/float64(b.N), "hit%")
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"time"
+)
+
+// shardedTable hashes keys across independently locked tables,
+// the size of the table is divided into the shards
+type shardedTable struct {
+	name   string
+	shards []TableCache
+}
+
+func newShardedTable(name string, opts Options) (*shardedTable, error) {
+	if err := opts.check(); err != nil {
+		return nil, err
+	}
+
+	// every shard has a part of the size and the max cost, 0 of a shard is unlimit
+	n := opts.Shards
+	if opts.Size > 0 && opts.Size < n {
+		n = opts.Size
+	}
+	if opts.MaxCost > 0 && opts.MaxCost < int64(n) {
+		n = int(opts.MaxCost)
+	}
+
+	t := &shardedTable{name: name, shards: make([]TableCache, n)}
+	for i := range t.shards {
+		shardOpts := opts
+		shardOpts.Shards = 0
+		if opts.Size > 0 {
+			// the sum of the shards' sizes is the size of the table
+			shardOpts.Size = opts.Size / n
+			if i < opts.Size%n {
+				shardOpts.Size++
+			}
+		}
+		if opts.MaxCost > 0 {
+			shardOpts.MaxCost = opts.MaxCost / int64(n)
+			if int64(i) < opts.MaxCost%int64(n) {
+				shardOpts.MaxCost++
+			}
+		}
+
+		var err error
+		if shardOpts.Policy == PolicyLRU {
+			t.shards[i], err = NewLRU(name, shardOpts)
+		} else {
+			t.shards[i], err = newPolicyTable(name, shardOpts)
+		}
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (p *shardedTable) shard(key interface{}) TableCache {
+	return p.shards[hashKey(key)%uint64(len(p.shards))]
+}
+
+func (p *shardedTable) Insert(key, value interface{}) bool {
+	return p.shard(key).Insert(key, value)
+}
+
+func (p *shardedTable) InsertExpire(key, value interface{}, expire time.Duration) bool {
+	return p.shard(key).InsertExpire(key, value, expire)
+}
+
+func (p *shardedTable) DeleteObject(key interface{}) bool {
+	return p.shard(key).DeleteObject(key)
+}
+
+func (p *shardedTable) DeleteObjects() {
+	for _, s := range p.shards {
+		s.DeleteObjects()
+	}
+}
+
+func (p *shardedTable) Member(key interface{}) bool {
+	return p.shard(key).Member(key)
+}
+
+func (p *shardedTable) Members() (keys []interface{}, ok bool) {
+	for _, s := range p.shards {
+		if ks, exists := s.Members(); exists {
+			keys = append(keys, ks...)
+			ok = true
+		}
+	}
+	return
+}
+
+func (p *shardedTable) Lookup(key interface{}) ([]interface{}, bool) {
+	return p.shard(key).Lookup(key)
+}
+
+func (p *shardedTable) LookupAll() (items map[interface{}][]interface{}, ok bool) {
+	for _, s := range p.shards {
+		all, exists := s.LookupAll()
+		if !exists {
+			continue
+		}
+		if items == nil {
+			items = make(map[interface{}][]interface{}, len(all)*len(p.shards))
+			ok = true
+		}
+		for k, vs := range all {
+			items[k] = vs
+		}
+	}
+	return
+}
+
+func (p *shardedTable) snapshot() *TableSnapshot {
+	s := &TableSnapshot{Name: p.name}
+	for _, shard := range p.shards {
+		ss := shard.(snapshotTable).snapshot()
+		s.ValueMode = ss.ValueMode
+		s.Entries = append(s.Entries, ss.Entries...)
+	}
+	return s
+}
+
+func (p *shardedTable) LookupIndex(index string, indexKey interface{}) (keys []interface{}, ok bool) {
+	for _, s := range p.shards {
+		if sk, exists := s.LookupIndex(index, indexKey); exists {
+			keys = append(keys, sk...)
+			ok = true
+		}
+	}
+	return
+}
+
+func (p *shardedTable) Range(fn func(key interface{}, values []interface{}) bool) {
+	next := true
+	for _, s := range p.shards {
+		s.Range(func(key interface{}, values []interface{}) bool {
+			next = fn(key, values)
+			return next
+		})
+		if !next {
+			return
+		}
+	}
+}
+
+func (p *shardedTable) Select(predicate func(key interface{}, values []interface{}) bool) (
+	map[interface{}][]interface{}, bool) {
+	return selectRange(p, predicate)
+}
+
+func (p *shardedTable) SetExpire(key interface{}, expire time.Duration) bool {
+	return p.shard(key).SetExpire(key, expire)
+}
+
+func (p *shardedTable) Close() {
+	for _, s := range p.shards {
+		if s != nil {
+			s.Close()
+		}
+	}
+}
+
+func (p *shardedTable) Stats() Stats {
+	var stats Stats
+	for _, s := range p.shards {
+		stats = stats.add(s.Stats())
+	}
+	return stats
+}
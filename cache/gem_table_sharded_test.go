@@ -0,0 +1,106 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestShardedTable(t *testing.T) {
+	for _, policy := range []cache.Policy{cache.PolicyLRU, cache.PolicyTinyLFU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tab, err := cache.NewTableCache("sharded",
+				cache.OptionShards(8), cache.OptionKeySize(100), cache.OptionPolicy(policy))
+			testutils.Ok(t, err)
+			defer tab.Close()
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						key := fmt.Sprintf("%d-%d", g, i)
+						tab.Insert(key, i)
+						tab.Lookup(key)
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			keys, ok := tab.Members()
+			testutils.Assert(t, ok, "members should exist")
+			testutils.Assert(t, len(keys) <= 100, "size %d should not be over 100", len(keys))
+
+			all, ok := tab.LookupAll()
+			testutils.Assert(t, ok, "all should exist")
+			testutils.Equals(t, len(keys), len(all))
+			for _, k := range keys {
+				testutils.Assert(t, tab.Member(k), "%v should be a member", k)
+			}
+
+			tab.DeleteObjects()
+			_, ok = tab.Members()
+			testutils.Assert(t, !ok, "table should be empty")
+		})
+	}
+
+	// shards are not more than the size
+	tab, err := cache.NewTableCache("small", cache.OptionShards(16), cache.OptionKeySize(2))
+	testutils.Ok(t, err)
+	for i := 0; i < 10; i++ {
+		tab.Insert(i, i)
+	}
+	keys, _ := tab.Members()
+	testutils.Assert(t, len(keys) <= 2, "size %d should not be over 2", len(keys))
+}
+
+func BenchmarkTableParallel(b *testing.B) {
+	const keys = 100000
+	for _, shards := range []int{1, 16, 64} {
+		b.Run(fmt.Sprintf("shards-%d", shards), func(b *testing.B) {
+			tab, err := cache.NewTableCache("parallel", cache.OptionShards(shards), cache.OptionKeySize(keys/2))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < keys/2; i++ {
+				tab.Insert(i, i)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(rand.Int63()))
+				for pb.Next() {
+					key := r.Intn(keys)
+					// 90% reads and 10% writes
+					if r.Intn(10) > 0 {
+						tab.Lookup(key)
+						continue
+					}
+					tab.Insert(key, key)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package invalidation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iTrellis/common/cache"
+)
+
+// Message tells peers to evict the key of the table, or all keys of the table
+type Message struct {
+	// the id of the cache which publishes the message
+	Source string      `json:"source"`
+	Table  string      `json:"table"`
+	Key    interface{} `json:"key,omitempty"`
+	All    bool        `json:"all,omitempty"`
+}
+
+// Transport publishes messages to peers and subscribes messages of peers
+type Transport interface {
+	// Publish sends the message to peers
+	Publish(msg *Message) error
+	// Subscribe calls fn with the received messages until cancel is called
+	Subscribe(fn func(*Message)) (cancel func(), err error)
+}
+
+// Options the options of the invalidation cache
+type Options struct {
+	// transport of messages, default: the in-process event bus
+	Transport Transport
+	// id of the cache, default: an uuid
+	Source string
+	// called if the message is failed to publish
+	OnError func(error)
+}
+
+// Option the function to set options
+type Option func(*Options)
+
+// OptionTransport set the transport of messages
+func OptionTransport(t Transport) Option {
+	return func(o *Options) {
+		o.Transport = t
+	}
+}
+
+// OptionSource set the id of the cache, messages of itself are ignored
+func OptionSource(source string) Option {
+	return func(o *Options) {
+		o.Source = source
+	}
+}
+
+// OptionOnError set the callback of errors of publishing
+func OptionOnError(fn func(error)) Option {
+	return func(o *Options) {
+		o.OnError = fn
+	}
+}
+
+// Cache publishes messages when keys are inserted or deleted by it,
+// and evicts the keys of messages published by peers.
+// Values changed by the tables got from GetTableCache are not published.
+type Cache struct {
+	cache.Cache
+
+	options Options
+	cancel  func()
+}
+
+// New returns the cache which invalidates the keys of peers
+func New(c cache.Cache, opts ...Option) (*Cache, error) {
+	p := &Cache{Cache: c}
+	for _, o := range opts {
+		o(&p.options)
+	}
+	if p.options.Transport == nil {
+		p.options.Transport = NewBusTransport()
+	}
+	if p.options.Source == "" {
+		p.options.Source = uuid.NewString()
+	}
+
+	cancel, err := p.options.Transport.Subscribe(p.invalidate)
+	if err != nil {
+		return nil, err
+	}
+	p.cancel = cancel
+	return p, nil
+}
+
+// Source returns the id of the cache
+func (p *Cache) Source() string {
+	return p.options.Source
+}
+
+func (p *Cache) Insert(tab string, key, value interface{}) bool {
+	ok := p.Cache.Insert(tab, key, value)
+	if ok {
+		p.publish(&Message{Table: tab, Key: key})
+	}
+	return ok
+}
+
+func (p *Cache) InsertExpire(tab string, key, value interface{}, expire time.Duration) bool {
+	ok := p.Cache.InsertExpire(tab, key, value, expire)
+	if ok {
+		p.publish(&Message{Table: tab, Key: key})
+	}
+	return ok
+}
+
+func (p *Cache) DeleteObject(tab string, key interface{}) bool {
+	ok := p.Cache.DeleteObject(tab, key)
+	p.publish(&Message{Table: tab, Key: key})
+	return ok
+}
+
+func (p *Cache) DeleteObjects(tab string) {
+	p.Cache.DeleteObjects(tab)
+	p.publish(&Message{Table: tab, All: true})
+}
+
+func (p *Cache) Delete(tab string) bool {
+	ok := p.Cache.Delete(tab)
+	p.publish(&Message{Table: tab, All: true})
+	return ok
+}
+
+// Close stops subscribing messages and closes the cache
+func (p *Cache) Close() error {
+	p.cancel()
+	return p.Cache.Close()
+}
+
+func (p *Cache) publish(msg *Message) {
+	msg.Source = p.options.Source
+	if err := p.options.Transport.Publish(msg); err != nil && p.options.OnError != nil {
+		p.options.OnError(err)
+	}
+}
+
+// invalidate evicts the key of the message of peers
+func (p *Cache) invalidate(msg *Message) {
+	if msg.Source == p.options.Source {
+		return
+	}
+	if msg.All {
+		p.Cache.DeleteObjects(msg.Table)
+		return
+	}
+	p.Cache.DeleteObject(msg.Table, msg.Key)
+}
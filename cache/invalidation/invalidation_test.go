@@ -0,0 +1,144 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package invalidation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/cache"
+	"github.com/iTrellis/common/cache/invalidation"
+	"github.com/iTrellis/common/discovery"
+	"github.com/iTrellis/common/event"
+	"github.com/iTrellis/common/testutils"
+)
+
+func newPeers(t *testing.T, opts ...invalidation.Option) (*invalidation.Cache, *invalidation.Cache) {
+	var peers []*invalidation.Cache
+	for i := 0; i < 2; i++ {
+		c, err := invalidation.New(cache.New(), opts...)
+		testutils.Ok(t, err)
+		testutils.Ok(t, c.New("users"))
+		peers = append(peers, c)
+	}
+	return peers[0], peers[1]
+}
+
+// receivedTransport tells the messages received by a cache
+type receivedTransport struct {
+	invalidation.Transport
+	received chan *invalidation.Message
+}
+
+func (p *receivedTransport) Subscribe(fn func(*invalidation.Message)) (func(), error) {
+	return p.Transport.Subscribe(func(msg *invalidation.Message) {
+		fn(msg)
+		p.received <- msg
+	})
+}
+
+// waitFrom waits the message published by the source is received
+func waitFrom(t *testing.T, received chan *invalidation.Message, source string) {
+	t.Helper()
+	for {
+		select {
+		case msg := <-received:
+			if msg.Source == source {
+				return
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("message of %s is not received", source)
+		}
+	}
+}
+
+func TestBusInvalidation(t *testing.T) {
+	a, b := newPeers(t, invalidation.OptionTransport(invalidation.NewBusTransport(event.NewEventCenter("test"))))
+	defer a.Close()
+
+	b.Insert("users", "alice", 1)
+	testutils.Assert(t, b.Member("users", "alice"), "the inserting cache keeps its value")
+
+	a.Insert("users", "alice", 2)
+	testutils.Assert(t, !b.Member("users", "alice"), "alice should be evicted by the peer insert")
+	testutils.Assert(t, a.Member("users", "alice"), "alice should be kept by the inserting cache")
+
+	b.Insert("users", "bob", 1)
+	b.Insert("users", "carol", 1)
+	a.DeleteObjects("users")
+	_, ok := b.Members("users")
+	testutils.Assert(t, !ok, "all keys should be evicted")
+
+	// closed caches do not receive messages
+	testutils.Ok(t, b.Close())
+	b.Cache.Insert("users", "dave", 1)
+	a.DeleteObject("users", "dave")
+	testutils.Assert(t, b.Member("users", "dave"), "closed cache should not be invalidated")
+}
+
+func TestDefaultBusInvalidation(t *testing.T) {
+	a, b := newPeers(t)
+	defer a.Close()
+	defer b.Close()
+
+	a.Insert("users", "alice", 1)
+	b.Insert("users", "alice", 2)
+	testutils.Assert(t, !a.Member("users", "alice"), "caches share the default bus")
+}
+
+func TestDiscoveryInvalidation(t *testing.T) {
+	client := discovery.NewInMemoryClient(invalidation.NewCodec())
+	transport := invalidation.NewDiscoveryTransport(client, "cache/invalidation")
+
+	var peers []*invalidation.Cache
+	var received []chan *invalidation.Message
+	for i := 0; i < 2; i++ {
+		ch := make(chan *invalidation.Message, 16)
+		c, err := invalidation.New(cache.New(),
+			invalidation.OptionTransport(&receivedTransport{Transport: transport, received: ch}))
+		testutils.Ok(t, err)
+		defer c.Close()
+		testutils.Ok(t, c.New("users"))
+		peers, received = append(peers, c), append(received, ch)
+	}
+	a, b := peers[0], peers[1]
+
+	b.Insert("users", "alice", 1)
+	waitFrom(t, received[0], b.Source())
+	a.Insert("users", "alice", 2)
+	waitFrom(t, received[1], a.Source())
+	testutils.Assert(t, !b.Member("users", "alice"), "alice should be evicted by the peer insert")
+	testutils.Assert(t, a.Member("users", "alice"), "alice should be kept by the inserting cache")
+
+	// the types of keys are kept
+	b.Insert("users", 1, "bob")
+	waitFrom(t, received[0], b.Source())
+	a.DeleteObject("users", 1)
+	waitFrom(t, received[1], a.Source())
+	testutils.Assert(t, !b.Member("users", 1), "1 should be evicted by the peer delete")
+
+	// the unregistered types of keys are failed to publish
+	var errs []error
+	c, err := invalidation.New(cache.New(), invalidation.OptionTransport(transport),
+		invalidation.OptionOnError(func(err error) { errs = append(errs, err) }))
+	testutils.Ok(t, err)
+	defer c.Close()
+	testutils.Ok(t, c.New("users"))
+	c.Insert("users", struct{ ID int }{1}, "carol")
+	testutils.Equals(t, 1, len(errs))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package invalidation
+
+import (
+	"github.com/iTrellis/common/event"
+)
+
+// EventInvalidation the event name of messages on the event bus
+const EventInvalidation = "trellis::cache::invalidation"
+
+// defaultBus is shared by the caches in the process
+var defaultBus = event.NewEventCenter("trellis::cache::invalidation-center")
+
+type busTransport struct {
+	bus event.Bus
+}
+
+// NewBusTransport returns the transport over the event bus, default: the bus shared in the process
+func NewBusTransport(bus ...event.Bus) Transport {
+	t := &busTransport{bus: defaultBus}
+	if len(bus) > 0 && bus[0] != nil {
+		t.bus = bus[0]
+	}
+	// the event is already registered by another transport of the bus
+	_ = t.bus.RegistEvent(EventInvalidation)
+	return t
+}
+
+func (p *busTransport) Publish(msg *Message) error {
+	p.bus.Publish(EventInvalidation, msg)
+	return nil
+}
+
+func (p *busTransport) Subscribe(fn func(*Message)) (func(), error) {
+	sub, err := p.bus.Subscribe(EventInvalidation, func(values ...interface{}) {
+		for _, v := range values {
+			if msg, ok := v.(*Message); ok {
+				fn(msg)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = p.bus.Unsubscribe(EventInvalidation, sub.GetID()) }, nil
+}
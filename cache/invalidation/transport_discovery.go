@@ -0,0 +1,63 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package invalidation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/iTrellis/common/codec"
+	"github.com/iTrellis/common/discovery"
+)
+
+type discoveryTransport struct {
+	client discovery.Client
+	prefix string
+}
+
+// NewCodec returns the gob codec of messages for the discovery client, the types of keys are kept,
+// keys which are not the basic types should be registered by gob.Register, or they are failed to publish
+func NewCodec() codec.Codec {
+	return codec.NewGobCodec("cache_invalidation", func() interface{} { return &Message{} })
+}
+
+// NewDiscoveryTransport returns the transport over the discovery client,
+// every cache writes its messages into prefix/source, and watches the prefix.
+// The client should serialise messages by NewCodec.
+func NewDiscoveryTransport(client discovery.Client, prefix string) Transport {
+	return &discoveryTransport{client: client, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+func (p *discoveryTransport) Publish(msg *Message) error {
+	return p.client.CAS(context.Background(), p.prefix+msg.Source,
+		func(interface{}) (interface{}, bool, error) {
+			return msg, true, nil
+		})
+}
+
+func (p *discoveryTransport) Subscribe(fn func(*Message)) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// messages published after subscribing are all received if the client is a discovery.ReadyWatcher
+	discovery.StartWatchPrefix(ctx, p.client, p.prefix, func(_ string, v interface{}) bool {
+		if msg, ok := v.(*Message); ok {
+			fn(msg)
+		}
+		return true
+	})
+	return cancel, nil
+}
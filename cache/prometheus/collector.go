@@ -0,0 +1,111 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package prometheus
+
+import (
+	"github.com/iTrellis/common/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects the stats of all tables in the cache
+type Collector struct {
+	c cache.Cache
+
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	inserts   *prometheus.Desc
+	evictions *prometheus.Desc
+	size      *prometheus.Desc
+	cost      *prometheus.Desc
+	loads     *prometheus.Desc
+	loadTime  *prometheus.Desc
+}
+
+// NewCollector returns a collector of the cache, metrics are named namespace_cache_*
+func NewCollector(c cache.Cache, namespace string) *Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help,
+			append([]string{"table"}, labels...), nil)
+	}
+	return &Collector{
+		c:         c,
+		hits:      desc("hits_total", "Number of lookups which found the key."),
+		misses:    desc("misses_total", "Number of lookups which did not find the key."),
+		inserts:   desc("inserts_total", "Number of inserted values."),
+		evictions: desc("evictions_total", "Number of removed keys by reason: capacity, expired, deleted.", "reason"),
+		size:      desc("size", "Number of keys in the table."),
+		cost:      desc("cost", "Total cost of values in the table."),
+		loads:     desc("loads_total", "Number of loads of the loading table by result: success, error.", "result"),
+		loadTime:  desc("load_duration_seconds", "Durations of loads of the loading table, including failed loads."),
+	}
+}
+
+// Register registers the collector of the cache into the registerer
+func Register(r prometheus.Registerer, c cache.Cache, namespace string) (*Collector, error) {
+	collector := NewCollector(c, namespace)
+	if err := r.Register(collector); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+// Describe implements prometheus.Collector
+func (p *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.hits
+	ch <- p.misses
+	ch <- p.inserts
+	ch <- p.evictions
+	ch <- p.size
+	ch <- p.cost
+	ch <- p.loads
+	ch <- p.loadTime
+}
+
+// Collect implements prometheus.Collector, tables are read when they are collected
+func (p *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, tab := range p.c.All() {
+		tc, ok := p.c.GetTableCache(tab)
+		if !ok {
+			continue
+		}
+		s := tc.Stats()
+
+		ch <- prometheus.MustNewConstMetric(p.hits, prometheus.CounterValue, float64(s.Hits), tab)
+		ch <- prometheus.MustNewConstMetric(p.misses, prometheus.CounterValue, float64(s.Misses), tab)
+		ch <- prometheus.MustNewConstMetric(p.inserts, prometheus.CounterValue, float64(s.Inserts), tab)
+		ch <- prometheus.MustNewConstMetric(p.evictions, prometheus.CounterValue, float64(s.Evictions),
+			tab, cache.EvictReasonCapacity.String())
+		ch <- prometheus.MustNewConstMetric(p.evictions, prometheus.CounterValue, float64(s.Expirations),
+			tab, cache.EvictReasonExpired.String())
+		ch <- prometheus.MustNewConstMetric(p.evictions, prometheus.CounterValue, float64(s.Deletions),
+			tab, cache.EvictReasonDeleted.String())
+		ch <- prometheus.MustNewConstMetric(p.size, prometheus.GaugeValue, float64(s.Size), tab)
+		ch <- prometheus.MustNewConstMetric(p.cost, prometheus.GaugeValue, float64(s.Cost), tab)
+
+		if _, loading := tc.(*cache.LoadingTable); !loading {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(p.loads, prometheus.CounterValue, float64(s.Loads), tab, "success")
+		ch <- prometheus.MustNewConstMetric(p.loads, prometheus.CounterValue, float64(s.LoadErrors), tab, "error")
+		buckets := make(map[float64]uint64, len(s.LoadDurations))
+		for i, n := range s.LoadDurations {
+			buckets[s.LoadBuckets[i].Seconds()] = n
+		}
+		ch <- prometheus.MustNewConstHistogram(p.loadTime, s.LoadCount, s.LoadTime.Seconds(), buckets, tab)
+	}
+}
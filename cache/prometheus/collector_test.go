@@ -0,0 +1,82 @@
+/*
+Copyright © 2016 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package prometheus_test
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/cache"
+	cacheprom "github.com/iTrellis/common/cache/prometheus"
+	"github.com/iTrellis/common/testutils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	c := cache.New()
+	testutils.Ok(t, c.New("users", cache.OptionKeySize(1)))
+	testutils.Ok(t, c.New("loading", cache.OptionLoader(func(key interface{}) (interface{}, error) {
+		return key, nil
+	})))
+
+	tab, _ := c.GetTableCache("loading")
+	tab.(*cache.LoadingTable).Get("a")
+
+	c.Insert("users", 1, "a")
+	c.Insert("users", 2, "b")
+	c.Lookup("users", 2)
+	c.Lookup("users", 1)
+
+	r := prometheus.NewRegistry()
+	_, err := cacheprom.Register(r, c, "test")
+	testutils.Ok(t, err)
+
+	families, err := r.Gather()
+	testutils.Ok(t, err)
+
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			key := f.GetName()
+			for _, l := range m.GetLabel() {
+				key += "," + l.GetName() + "=" + l.GetValue()
+			}
+			switch {
+			case m.GetCounter() != nil:
+				values[key] = m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				h := m.GetHistogram()
+				values[key+",count"] = float64(h.GetSampleCount())
+				values[key+",buckets"] = float64(len(h.GetBucket()))
+			default:
+				values[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	testutils.Equals(t, float64(1), values["test_cache_hits_total,table=users"])
+	testutils.Equals(t, float64(1), values["test_cache_misses_total,table=users"])
+	testutils.Equals(t, float64(2), values["test_cache_inserts_total,table=users"])
+	testutils.Equals(t, float64(1), values["test_cache_evictions_total,reason=capacity,table=users"])
+	testutils.Equals(t, float64(1), values["test_cache_size,table=users"])
+	testutils.Equals(t, float64(1), values["test_cache_cost,table=users"])
+	testutils.Equals(t, float64(1), values["test_cache_loads_total,result=success,table=loading"])
+	testutils.Equals(t, float64(1), values["test_cache_load_duration_seconds,table=loading,count"])
+	testutils.Equals(t, float64(len(tab.Stats().LoadBuckets)), values["test_cache_load_duration_seconds,table=loading,buckets"])
+	_, ok := values["test_cache_loads_total,result=success,table=users"]
+	testutils.Assert(t, !ok, "users is not a loading table")
+}
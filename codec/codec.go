@@ -18,6 +18,9 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package codec
 
 import (
+	"bytes"
+	"encoding/gob"
+
 	"github.com/golang/snappy"
 	"github.com/iTrellis/common/json"
 	"google.golang.org/protobuf/proto"
@@ -27,6 +30,7 @@ var (
 	_ Codec = (*Proto)(nil)
 	_ Codec = (*String)(nil)
 	_ Codec = (*JSON)(nil)
+	_ Codec = (*Gob)(nil)
 )
 
 // NewCodec Takes in a connection/buffer and returns a new Codec
@@ -119,3 +123,36 @@ func (p *JSON) Unmarshal(msg []byte) (interface{}, error) {
 func (p *JSON) Marshal(msg interface{}) ([]byte, error) {
 	return json.Marshal(msg)
 }
+
+// Gob is a Codec for gob, it keeps the types of values in interfaces,
+// which should be registered by gob.Register if they are not the basic types
+type Gob struct {
+	id      string
+	factory func() interface{}
+}
+
+func NewGobCodec(id string, factory func() interface{}) *Gob {
+	return &Gob{id: id, factory: factory}
+}
+
+func (*Gob) String() string {
+	return "gob"
+}
+
+// Unmarshal implements Codec.
+func (p *Gob) Unmarshal(msg []byte) (interface{}, error) {
+	out := p.factory()
+	if err := gob.NewDecoder(bytes.NewReader(msg)).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Marshal implements Codec.
+func (p *Gob) Marshal(msg interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -72,8 +72,14 @@ type Config interface {
 	GetBoolean(key string, defValue ...bool) (b bool)
 	// get a int
 	GetInt(key string, defValue ...int) (res int)
+	// get a int64
+	GetInt64(key string, defValue ...int64) int64
+	// get a uint
+	GetUint(key string, defValue ...uint) uint
 	// get a float
 	GetFloat(key string, defValue ...float64) (res float64)
+	// get a time by RFC3339 or layouts in formats, exp: 2006-01-02 15:04:05
+	GetTime(key string, defValue ...time.Time) time.Time
 	// get list of objects
 	GetList(key string) (res []interface{})
 	// get list of strings
@@ -84,12 +90,18 @@ type Config interface {
 	GetIntList(key string) []int
 	// get list of float64s
 	GetFloatList(key string) []float64
+	// get list of time durations
+	GetDurationList(key string) []time.Duration
 	// get time duration by (int)(uint), exp: 1s, 1day
 	GetTimeDuration(key string, defValue ...time.Duration) time.Duration
 	// get byte size by (int)(uint), exp: 1k, 1m
 	GetByteSize(key string, defValue ...*big.Int) *big.Int
 	// get map value
 	GetMap(key string) Options
+	// get map value
+	GetStringMap(key string) map[string]interface{}
+	// get map of strings
+	GetStringMapString(key string) map[string]string
 	// get key's config
 	GetConfig(key string) Config
 	// ToObject unmarshal values to object
@@ -100,8 +112,8 @@ type Config interface {
 	SetKeyValue(key string, value interface{}) (err error)
 	// get all config
 	Dump() (bs []byte, err error)
-	// get all keys
-	GetKeys() []string
+	// get the top level keys, or the key paths matching the pattern, exp: servers[*].host, db.*
+	GetKeys(pattern ...string) []string
 	// deep copy configs
 	Copy() Config
 }
@@ -30,7 +30,7 @@ import (
 )
 
 const (
-	includeReg = `\$\{([0-9|a-z|A-Z|_|-]|\.)+\}`
+	includeReg = `\$\{((?:[0-9a-zA-Z_.\-\[\]*]|"(?:[^"\\]|\\.)*")+)(:-([^}]*))?\}`
 )
 
 // AdapterConfig default config adapter
@@ -41,6 +41,7 @@ type AdapterConfig struct {
 
 	EnvPrefix  string
 	EnvAllowed bool
+	EnvOverlay bool
 
 	data []byte
 
@@ -49,6 +50,20 @@ type AdapterConfig struct {
 	reader  Reader
 	locker  sync.RWMutex
 	configs map[string]interface{}
+
+	watchInterval time.Duration
+	watchLocker   sync.Mutex
+	stopWatch     chan struct{}
+	onChanges     []ChangeFunc
+	onWatchError  func(error)
+
+	validators  []Validator
+	overrides   Options
+	keyProvider KeyProvider
+
+	historySize int
+	history     []*Snapshot
+	version     int
 }
 
 // NewAdapterConfig return default config adapter
@@ -90,6 +105,12 @@ func (p *AdapterConfig) init(opts ...OptionFunc) (err error) {
 		p.reader = NewJSONReader(ReaderOptionFilename(p.ConfigFile))
 	case ReaderTypeYAML:
 		p.reader = NewYAMLReader(ReaderOptionFilename(p.ConfigFile))
+	case ReaderTypeXML:
+		p.reader = NewXMLReader(ReaderOptionFilename(p.ConfigFile))
+	case ReaderTypeTOML:
+		p.reader = NewTOMLReader(ReaderOptionFilename(p.ConfigFile))
+	case ReaderTypeINI:
+		p.reader = NewINIReader(ReaderOptionFilename(p.ConfigFile))
 	default:
 		return ErrNotSupportedReaderType
 	}
@@ -109,17 +130,34 @@ func (p *AdapterConfig) init(opts ...OptionFunc) (err error) {
 		return
 	}
 
-	return p.copyDollarSymbol("", &p.configs)
+	if err = p.resolve(); err != nil {
+		return
+	}
+
+	p.locker.Lock()
+	p.record("init")
+	p.locker.Unlock()
+
+	return p.watch()
 }
 
-// GetKeys get map keys
-func (p *AdapterConfig) GetKeys() []string {
+// GetKeys get map keys, or the sorted key paths matching the pattern,
+// '*' matches any key of a map or any item of a list, exp: servers[*].host, db.*
+func (p *AdapterConfig) GetKeys(pattern ...string) []string {
 	p.locker.RLock()
 	defer p.locker.RUnlock()
 
+	if len(pattern) > 0 && pattern[0] != "" {
+		tokens, err := parseKey(pattern[0])
+		if err != nil {
+			return nil
+		}
+		return matchKeys("", p.configs, tokens)
+	}
+
 	var keys []string
 	for key := range p.configs {
-		keys = append(keys, key)
+		keys = append(keys, quoteKey(key))
 	}
 	return keys
 }
@@ -130,14 +168,25 @@ func (p *AdapterConfig) copy() *AdapterConfig {
 
 	values := DeepCopy(p.configs)
 
-	valuesMap := values.(map[string]interface{})
+	return p.clone(values.(map[string]interface{}))
+}
+
+// clone return a config with the options of p and the configs,
+// the watcher, the callbacks and the history are not cloned
+func (p *AdapterConfig) clone(configs map[string]interface{}) *AdapterConfig {
 	return &AdapterConfig{
 		ConfigFile:   p.ConfigFile,
 		ConfigString: p.ConfigString,
 		ConfigStruct: p.ConfigStruct,
+		EnvPrefix:    p.EnvPrefix,
+		EnvAllowed:   p.EnvAllowed,
+		EnvOverlay:   p.EnvOverlay,
 		readerType:   p.readerType,
 		reader:       p.reader,
-		configs:      valuesMap,
+		configs:      configs,
+		validators:   p.validators,
+		overrides:    p.overrides,
+		keyProvider:  p.keyProvider,
 	}
 }
 
@@ -172,7 +221,7 @@ func (p *AdapterConfig) GetInterface(key string, defValue ...interface{}) (res i
 		return ErrInvalidKey
 	}
 
-	v, err = p.getKeyValue(key)
+	v, err = p.GetKeyValue(key)
 	return
 }
 
@@ -188,10 +237,23 @@ func (p *AdapterConfig) GetString(key string, defValue ...string) (res string) {
 	}()
 	v := p.GetInterface(key, defValue)
 
-	res, ok = v.(string)
+	res, ok = p.stringValue(v)
 	return
 }
 
+// stringValue return the string of value,
+// scalars inferred by the readers of formats without types (xml, ini) are converted back
+func (p *AdapterConfig) stringValue(v interface{}) (string, bool) {
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	if v == nil || (p.readerType != ReaderTypeXML && p.readerType != ReaderTypeINI) {
+		return "", false
+	}
+	s, err := castString(v)
+	return s, err == nil
+}
+
 // GetBoolean return a bool object in p.configs by key
 func (p *AdapterConfig) GetBoolean(key string, defValue ...bool) (b bool) {
 
@@ -212,7 +274,8 @@ func (p *AdapterConfig) GetBoolean(key string, defValue ...bool) (b bool) {
 	case reflect.Bool:
 		ok, b = true, v.(bool)
 	case reflect.String:
-		ok, b = true, strings.ToLower(v.(string)) == "on"
+		b, _ = toBool(v)
+		ok = true
 	}
 
 	return
@@ -280,7 +343,7 @@ func (p *AdapterConfig) GetStringList(key string) []string {
 
 	var items []string
 	for _, v := range p.GetList(key) {
-		item, ok := v.(string)
+		item, ok := p.stringValue(v)
 		if !ok {
 			return nil
 		}
@@ -336,45 +399,45 @@ func (p *AdapterConfig) GetFloatList(key string) []float64 {
 // GetMap get map value
 func (p *AdapterConfig) GetMap(key string) Options {
 
-	vm, err := p.getKeyValue(key)
+	vm, err := p.GetKeyValue(key)
 	if err != nil {
 		return nil
 	}
 
-	switch t := vm.(type) {
-	case Options:
-		return t
-	case map[string]interface{}:
-		return t
-	case map[interface{}]interface{}:
-		result := make(map[string]interface{})
-		for k, v := range t {
-			sk, ok := k.(string)
-			if !ok {
-				continue
-			}
-			result[sk] = v
-		}
-		return result
-	default:
+	result, ok := toStringMap(vm)
+	if !ok {
 		return nil
 	}
+	return result
 }
 
 // GetConfig return object config in p.configs by key
 func (p *AdapterConfig) GetConfig(key string) Config {
 
-	vm, err := p.getKeyValue(key)
+	tokens, err := parseKey(key)
 	if err != nil {
 		return nil
 	}
 
-	c := &AdapterConfig{
-		reader:  p.reader,
-		configs: map[string]interface{}{key: vm},
+	vm, err := p.GetKeyValue(key)
+	if err != nil {
+		return nil
+	}
+
+	// keep the key path, indexes of lists are map keys in the new config
+	for i := range tokens {
+		tokens[i].index = false
+	}
+	configs := make(map[string]interface{})
+	if _, err = setTokens(configs, tokens, vm); err != nil {
+		return nil
 	}
 
-	return c
+	return &AdapterConfig{
+		readerType: p.readerType,
+		reader:     p.reader,
+		configs:    configs,
+	}
 }
 
 // ToObject unmarshal values to object
@@ -382,21 +445,35 @@ func (p *AdapterConfig) ToObject(key string, model interface{}) (err error) {
 
 	var vm interface{}
 	if key != "" {
-		vm, err = p.getKeyValue(key)
+		vm, err = p.GetKeyValue(key)
 		if err != nil {
 			return
 		}
 	} else {
-		vm = p.copy().configs
+		vm, err = p.decryptValue(p.copy().configs)
+		if err != nil {
+			return
+		}
 	}
 
 	switch p.readerType {
 	case ReaderTypeJSON:
 		bs, _ := json.Marshal(vm)
 		err = json.Unmarshal(bs, model)
-	case ReaderTypeYAML:
+	case ReaderTypeYAML, ReaderTypeXML, ReaderTypeINI:
 		bs, _ := yaml.Marshal(vm)
 		err = yaml.Unmarshal(bs, model)
+	case ReaderTypeTOML:
+		m, ok := toStringMap(vm)
+		if !ok {
+			bs, _ := yaml.Marshal(vm)
+			return yaml.Unmarshal(bs, model)
+		}
+		bs, e := p.reader.Dump(m)
+		if e != nil {
+			return e
+		}
+		err = p.reader.ParseData(bs, model)
 	}
 	return err
 }
@@ -407,14 +484,18 @@ func (p *AdapterConfig) GetValuesConfig(key string) Config {
 	return opt.ToConfig(p.readerType)
 }
 
-// GetKeyValue get value with key
+// GetKeyValue get value with key, encrypted values are decrypted
 func (p *AdapterConfig) GetKeyValue(key string) (vm interface{}, err error) {
 	if len(key) == 0 {
 		return nil, ErrInvalidKey
 	}
 	p.locker.RLock()
-	defer p.locker.RUnlock()
-	return p.getKeyValue(key)
+	vm, err = p.getKeyValue(key)
+	p.locker.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return p.decryptValue(vm)
 }
 
 // SetKeyValue set key value into p.configs
@@ -424,7 +505,11 @@ func (p *AdapterConfig) SetKeyValue(key string, value interface{}) (err error) {
 	}
 	p.locker.Lock()
 	defer p.locker.Unlock()
-	return p.setKeyValue(key, value)
+	if err = p.setKeyValue(key, value); err != nil {
+		return
+	}
+	p.record("set " + key)
+	return
 }
 
 // Dump return p.configs' bytes
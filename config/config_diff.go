@@ -0,0 +1,165 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/iTrellis/common/json"
+)
+
+// ChangeType define the type of changed key
+type ChangeType int
+
+const (
+	// ChangeAdded the key is added
+	ChangeAdded ChangeType = iota + 1
+	// ChangeRemoved the key is removed
+	ChangeRemoved
+	// ChangeModified the value of key is modified
+	ChangeModified
+)
+
+func (p ChangeType) String() string {
+	switch p {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change a changed key path with its old and new values
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff return the changes from a to b sorted by key path,
+// maps are compared by their keys, other values (lists) are compared entirely;
+// encrypted values are compared without decrypting
+func Diff(a, b Config) []Change {
+	return diffValues("", rawValues(a), rawValues(b))
+}
+
+// rawValues return the values of config without decrypting
+func rawValues(c Config) map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	if r, ok := c.(interface {
+		rawValues() map[string]interface{}
+	}); ok {
+		return r.rawValues()
+	}
+	return configValues(c)
+}
+
+func (p *AdapterConfig) rawValues() map[string]interface{} {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	return DeepCopy(p.configs).(map[string]interface{})
+}
+
+func diffValues(prefix string, olds, news map[string]interface{}) []Change {
+	var changes []Change
+	for k, ov := range olds {
+		key := joinKey(prefix, k)
+		nv, ok := news[k]
+		if !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeRemoved, Old: ov})
+			continue
+		}
+		om, oOk := toStringMap(ov)
+		nm, nOk := toStringMap(nv)
+		if oOk && nOk {
+			changes = append(changes, diffValues(key, om, nm)...)
+			continue
+		}
+		if !equalValues(ov, nv) {
+			changes = append(changes, Change{Key: key, Type: ChangeModified, Old: ov, New: nv})
+		}
+	}
+	for k, nv := range news {
+		if _, ok := olds[k]; !ok {
+			changes = append(changes, Change{Key: joinKey(prefix, k), Type: ChangeAdded, New: nv})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// equalValues compare values deeply, numbers are equal if their values are equal,
+// exp: int 1 of yaml and float64 1 of json
+func equalValues(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	if isNumber(a) && isNumber(b) {
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		return fa == fb
+	}
+
+	if am, ok := toStringMap(a); ok {
+		bm, ok := toStringMap(b)
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for k, v := range am {
+			if bv, ok := bm[k]; !ok || !equalValues(v, bv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	as, bs := reflect.ValueOf(a), reflect.ValueOf(b)
+	if a == nil || b == nil || as.Kind() != reflect.Slice || bs.Kind() != reflect.Slice || as.Len() != bs.Len() {
+		return false
+	}
+	for i := 0; i < as.Len(); i++ {
+		if !equalValues(as.Index(i).Interface(), bs.Index(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(v interface{}) bool {
+	if _, ok := v.(json.Number); ok {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML,
+		"db:\n  host: localhost\n  port: 3306\ntags: [a, b]\nname: x\n"))
+	testutils.Ok(t, err)
+	b, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeJSON,
+		`{"db": {"host": "db.local", "port": 3306, "user": "root"}, "tags": ["a"]}`))
+	testutils.Ok(t, err)
+
+	changes := config.Diff(a, b)
+	testutils.Equals(t, 4, len(changes))
+
+	testutils.Equals(t, "db.host", changes[0].Key)
+	testutils.Equals(t, config.ChangeModified, changes[0].Type)
+	testutils.Equals(t, "localhost", changes[0].Old)
+	testutils.Equals(t, "db.local", changes[0].New)
+
+	testutils.Equals(t, "db.user", changes[1].Key)
+	testutils.Equals(t, config.ChangeAdded, changes[1].Type)
+	testutils.Equals(t, "root", changes[1].New)
+
+	testutils.Equals(t, "name", changes[2].Key)
+	testutils.Equals(t, "removed", changes[2].Type.String())
+	testutils.Equals(t, "x", changes[2].Old)
+
+	testutils.Equals(t, "tags", changes[3].Key)
+	testutils.Equals(t, config.ChangeModified, changes[3].Type)
+
+	testutils.Equals(t, 0, len(config.Diff(a, a.Copy())))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// DefaultHistorySize default number of snapshots kept in the history
+const DefaultHistorySize = 10
+
+// Snapshot a version of configs recorded in the history
+type Snapshot struct {
+	Version int
+	Time    time.Time
+	// what made the snapshot, exp: init, set a.b, reload, rollback 2
+	Reason string
+	// changes from the previous snapshot
+	Changes []Change
+
+	configs map[string]interface{}
+}
+
+// OptionHistory record a snapshot on init, every SetKeyValue, reload and rollback,
+// the last size snapshots are kept
+func OptionHistory(size ...int) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.historySize = DefaultHistorySize
+		if len(size) > 0 && size[0] > 0 {
+			c.historySize = size[0]
+		}
+	}
+}
+
+// History return the kept snapshots, the oldest first
+func (p *AdapterConfig) History() []Snapshot {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	snapshots := make([]Snapshot, 0, len(p.history))
+	for _, s := range p.history {
+		snapshots = append(snapshots, Snapshot{
+			Version: s.Version,
+			Time:    s.Time,
+			Reason:  s.Reason,
+			Changes: s.Changes,
+		})
+	}
+	return snapshots
+}
+
+// Rollback restore the configs of the snapshot's version, it is recorded as a new snapshot;
+// the config file is not changed, so a watched config is overwritten by the next reload
+func (p *AdapterConfig) Rollback(version int) error {
+	p.locker.Lock()
+	var snapshot *Snapshot
+	for _, s := range p.history {
+		if s.Version == version {
+			snapshot = s
+			break
+		}
+	}
+	if snapshot == nil {
+		p.locker.Unlock()
+		return ErrSnapshotNotFound
+	}
+
+	olds := p.configs
+	p.configs = DeepCopy(snapshot.configs).(map[string]interface{})
+	p.record("rollback " + strconv.Itoa(version))
+	news := p.configs
+	p.locker.Unlock()
+
+	p.notifyChanges(changedKeys("", olds, news))
+	return nil
+}
+
+// record append a snapshot of p.configs if they were changed, p.locker must be held
+func (p *AdapterConfig) record(reason string) {
+	if p.historySize <= 0 {
+		return
+	}
+
+	var olds map[string]interface{}
+	if n := len(p.history); n > 0 {
+		olds = p.history[n-1].configs
+	}
+	configs := DeepCopy(p.configs).(map[string]interface{})
+	changes := diffValues("", olds, configs)
+	if len(p.history) > 0 && len(changes) == 0 {
+		return
+	}
+
+	p.version++
+	p.history = append(p.history, &Snapshot{
+		Version: p.version,
+		Time:    time.Now(),
+		Reason:  reason,
+		Changes: changes,
+		configs: configs,
+	})
+	if len(p.history) > p.historySize {
+		p.history = p.history[len(p.history)-p.historySize:]
+	}
+}
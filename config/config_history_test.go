@@ -0,0 +1,64 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestHistory(t *testing.T) {
+	var changed []string
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "db:\n  host: localhost\n  port: 3306\n"),
+		config.OptionHistory(3),
+		config.OptionOnChange(func(keys []string) { changed = keys }))
+	testutils.Ok(t, err)
+	ac := c.(*config.AdapterConfig)
+
+	history := ac.History()
+	testutils.Equals(t, 1, len(history))
+	testutils.Equals(t, "init", history[0].Reason)
+
+	testutils.Ok(t, c.SetKeyValue("db.host", "db.local"))
+	// nothing changed, no snapshot
+	testutils.Ok(t, c.SetKeyValue("db.host", "db.local"))
+	testutils.Ok(t, c.SetKeyValue("db.user", "root"))
+
+	history = ac.History()
+	testutils.Equals(t, 3, len(history))
+	testutils.Equals(t, 2, history[1].Version)
+	testutils.Equals(t, "set db.host", history[1].Reason)
+	testutils.Equals(t, []config.Change{{Key: "db.host", Type: config.ChangeModified, Old: "localhost", New: "db.local"}},
+		history[1].Changes)
+
+	testutils.Ok(t, ac.Rollback(1))
+	testutils.Equals(t, "localhost", c.GetString("db.host"))
+	testutils.Equals(t, "", c.GetString("db.user"))
+	testutils.Equals(t, []string{"db.host", "db.user"}, changed)
+
+	history = ac.History()
+	testutils.Equals(t, 3, len(history))
+	testutils.Equals(t, 2, history[0].Version)
+	testutils.Equals(t, "rollback 1", history[2].Reason)
+
+	// the oldest snapshot is dropped
+	testutils.ErrorEqual(t, config.ErrSnapshotNotFound, ac.Rollback(1))
+}
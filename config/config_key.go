@@ -0,0 +1,307 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyWildcard matches any key of a map or any item of a list in GetKeys' patterns
+const keyWildcard = "*"
+
+// keyToken a segment of the key path:
+// exp: servers[0]."host.name" => servers, [0], host.name
+type keyToken struct {
+	name     string
+	index    bool
+	quoted   bool
+	wildcard bool
+}
+
+// listIndex return the token as an index of a list
+func (p keyToken) listIndex() (int, bool) {
+	i, err := strconv.Atoi(p.name)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+// parseKey split the key path into tokens:
+// segments are separated by '.', a segment with special characters is quoted, exp: a."b.c".d;
+// items of a list are addressed by [n] or .n, exp: servers[0].host, servers.0.host;
+// '*' or [*] is the wildcard of GetKeys' patterns
+func parseKey(key string) ([]keyToken, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	var tokens []keyToken
+	for i := 0; i < len(key); {
+		switch {
+		case key[i] == '[':
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 || len(tokens) == 0 {
+				return nil, ErrInvalidKey
+			}
+			t := keyToken{name: key[i+1 : i+end], index: true}
+			if t.name == keyWildcard {
+				t.wildcard = true
+			} else if _, ok := t.listIndex(); !ok {
+				return nil, ErrInvalidKey
+			}
+			tokens = append(tokens, t)
+			i += end + 1
+		case key[i] == '.':
+			if len(tokens) == 0 || i+1 == len(key) || key[i+1] == '.' || key[i+1] == '[' {
+				return nil, ErrInvalidKey
+			}
+			i++
+		case len(tokens) > 0 && key[i-1] != '.':
+			// a segment must follow a '.' or be the first one
+			return nil, ErrInvalidKey
+		case key[i] == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(key) && key[j] != '"'; j++ {
+				if key[j] == '\\' && j+1 < len(key) {
+					j++
+				}
+				b.WriteByte(key[j])
+			}
+			if j == len(key) {
+				return nil, ErrInvalidKey
+			}
+			tokens = append(tokens, keyToken{name: b.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(key) && key[j] != '.' && key[j] != '[' && key[j] != '"' && key[j] != ']' {
+				j++
+			}
+			if j == i {
+				return nil, ErrInvalidKey
+			}
+			t := keyToken{name: key[i:j]}
+			t.wildcard = t.name == keyWildcard
+			tokens = append(tokens, t)
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// quoteKey quote the segment of key path if it has special characters
+func quoteKey(name string) string {
+	if name != "" && name != keyWildcard && !strings.ContainsAny(name, `."[]\`) {
+		return name
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
+
+// unquoteKey return the name of the quoted segment, exp: "example.com" => example.com
+func unquoteKey(key string) string {
+	tokens, err := parseKey(key)
+	if err != nil || len(tokens) != 1 {
+		return key
+	}
+	return tokens[0].name
+}
+
+// joinKey append the map's key to the key path
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return quoteKey(key)
+	}
+	return prefix + "." + quoteKey(key)
+}
+
+// indexKey append the list's index to the key path
+func indexKey(prefix string, i int) string {
+	return prefix + "[" + strconv.Itoa(i) + "]"
+}
+
+// normalizeKey return the key path in the canonical format, exp: a."b".c => a.b.c
+func normalizeKey(key string) string {
+	tokens, err := parseKey(key)
+	if err != nil {
+		return key
+	}
+	var path string
+	for _, t := range tokens {
+		if t.index {
+			path += "[" + t.name + "]"
+			continue
+		}
+		path = joinKey(path, t.name)
+	}
+	return path
+}
+
+// childValue return the value of the token in the map or the list
+func childValue(value interface{}, t keyToken) (interface{}, bool, error) {
+	switch v := value.(type) {
+	case Options:
+		child, ok := v[t.name]
+		return child, ok, nil
+	case map[string]interface{}:
+		child, ok := v[t.name]
+		return child, ok, nil
+	case map[interface{}]interface{}:
+		child, ok := v[t.name]
+		return child, ok, nil
+	}
+
+	vs := reflect.ValueOf(value)
+	if value == nil || vs.Kind() != reflect.Slice {
+		return nil, false, ErrNotMap
+	}
+	i, ok := t.listIndex()
+	if !ok {
+		return nil, false, ErrInvalidKey
+	}
+	if i >= vs.Len() {
+		return nil, false, nil
+	}
+	return vs.Index(i).Interface(), true, nil
+}
+
+// lookupTokens return the value of the tokens in value, ok is false if the key is not found
+func lookupTokens(value interface{}, tokens []keyToken) (interface{}, bool, error) {
+	for _, t := range tokens {
+		if t.wildcard {
+			return nil, false, ErrInvalidKey
+		}
+		var ok bool
+		var err error
+		if value, ok, err = childValue(value, t); err != nil || !ok {
+			return nil, false, err
+		}
+	}
+	return value, true, nil
+}
+
+// setTokens set the value of the tokens into the container and return the container,
+// a new container is made if it is not a map or a list: a list for [n], or a map
+func setTokens(container interface{}, tokens []keyToken, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	t := tokens[0]
+	if t.wildcard {
+		return nil, ErrInvalidKey
+	}
+
+	switch v := container.(type) {
+	case Options:
+		child, err := setTokens(v[t.name], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[t.name] = child
+		return v, nil
+	case map[string]interface{}:
+		child, err := setTokens(v[t.name], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[t.name] = child
+		return v, nil
+	case map[interface{}]interface{}:
+		child, err := setTokens(v[t.name], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[t.name] = child
+		return v, nil
+	}
+
+	vs := reflect.ValueOf(container)
+	if container == nil || vs.Kind() != reflect.Slice {
+		if !t.index {
+			return setTokens(map[string]interface{}{}, tokens, value)
+		}
+		vs = reflect.ValueOf([]interface{}{})
+	}
+
+	i, ok := t.listIndex()
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	if i > vs.Len() {
+		return nil, ErrIndexOutOfRange
+	}
+
+	list := make([]interface{}, vs.Len(), vs.Len()+1)
+	for j := range list {
+		list[j] = vs.Index(j).Interface()
+	}
+	if i == len(list) {
+		list = append(list, nil)
+	}
+
+	child, err := setTokens(list[i], tokens[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	list[i] = child
+	return list, nil
+}
+
+// matchKeys return the sorted key paths in value which match the tokens
+func matchKeys(prefix string, value interface{}, tokens []keyToken) []string {
+	if len(tokens) == 0 {
+		return []string{prefix}
+	}
+
+	t := tokens[0]
+	if !t.wildcard {
+		child, ok, err := childValue(value, t)
+		if err != nil || !ok {
+			return nil
+		}
+		if _, isMap := toStringMap(value); !isMap {
+			return matchKeys(prefix+"["+t.name+"]", child, tokens[1:])
+		}
+		return matchKeys(joinKey(prefix, t.name), child, tokens[1:])
+	}
+
+	var keys []string
+	if m, ok := toStringMap(value); ok {
+		names := make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			keys = append(keys, matchKeys(joinKey(prefix, k), m[k], tokens[1:])...)
+		}
+		return keys
+	}
+
+	if vs := reflect.ValueOf(value); value != nil && vs.Kind() == reflect.Slice {
+		for i := 0; i < vs.Len(); i++ {
+			keys = append(keys, matchKeys(indexKey(prefix, i), vs.Index(i).Interface(), tokens[1:])...)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+const keyYAML = `
+servers:
+  - host: a.local
+    port: 80
+  - host: b.local
+    port: 81
+"example.com":
+  port: 443
+db:
+  host: localhost
+  port: 3306
+url: http://${servers[1].host}:${"example.com".port}
+`
+
+func TestKeyPath(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, keyYAML))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "a.local", c.GetString("servers[0].host"))
+	testutils.Equals(t, "b.local", c.GetString("servers.1.host"))
+	testutils.Equals(t, 443, c.GetInt(`"example.com".port`))
+	testutils.Equals(t, "http://b.local:443", c.GetString("url"))
+	testutils.Equals(t, "", c.GetString("servers[2].host"))
+
+	for _, key := range []string{"servers[0", "servers[x]", `"example.com`, "db..host", "db.", "[0]", "servers[0]host"} {
+		_, err = c.(*config.AdapterConfig).GetKeyValue(key)
+		testutils.ErrorEqual(t, config.ErrInvalidKey, err)
+	}
+
+	testutils.Ok(t, c.SetKeyValue("servers[0].port", 8080))
+	testutils.Equals(t, 8080, c.GetInt("servers[0].port"))
+	testutils.Ok(t, c.SetKeyValue("servers[2]", map[string]interface{}{"host": "c.local"}))
+	testutils.Equals(t, "c.local", c.GetString("servers[2].host"))
+	testutils.ErrorEqual(t, config.ErrIndexOutOfRange, c.SetKeyValue("servers[5].host", "x"))
+
+	testutils.Ok(t, c.SetKeyValue(`labels."app.kubernetes.io/name"`, "demo"))
+	testutils.Equals(t, "demo", c.GetStringMapString("labels")["app.kubernetes.io/name"])
+	testutils.Ok(t, c.SetKeyValue("tags[0]", "x"))
+	testutils.Equals(t, []string{"x"}, c.GetStringList("tags"))
+
+	testutils.Equals(t, []string{"servers[0].host", "servers[1].host", "servers[2].host"}, c.GetKeys("servers[*].host"))
+	testutils.Equals(t, []string{"db.host", "db.port"}, c.GetKeys("db.*"))
+	testutils.Equals(t, []string{"db.port", `"example.com".port`}, c.GetKeys("*.port"))
+	keys := c.GetKeys()
+	testutils.Equals(t, 6, len(keys))
+	for _, key := range keys {
+		testutils.Assert(t, c.GetInterface(key) != nil, "top level key %s should be got", key)
+	}
+	testutils.Equals(t, 443, c.GetConfig(`"example.com"`).GetInt(`"example.com".port`))
+
+	sc := c.GetConfig("servers[1]")
+	testutils.Assert(t, sc != nil, "servers[1] config should not be nil")
+	testutils.Equals(t, "b.local", sc.GetString("servers[1].host"))
+}
+
+type dottedKeyModel struct {
+	Site struct {
+		Port int `yaml:"port" validate:"required"`
+	} `yaml:"example.com" validate:"required"`
+}
+
+// wrappedConfig hides the raw values of AdapterConfig, so configs are read by GetKeys
+type wrappedConfig struct {
+	config.Config
+}
+
+func TestDottedTopLevelKeys(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "example.com:\n  port: 80\n"),
+		config.OptionValidator(config.NewStructValidator(dottedKeyModel{})))
+	testutils.Ok(t, err)
+	testutils.Equals(t, []string{`"example.com"`}, c.GetKeys())
+
+	b, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, "example.com:\n  port: 8080\n"))
+	testutils.Ok(t, err)
+
+	changes := config.Diff(wrappedConfig{c}, wrappedConfig{b})
+	testutils.Equals(t, 1, len(changes))
+	testutils.Equals(t, `"example.com".port`, changes[0].Key)
+	testutils.Equals(t, config.ChangeModified, changes[0].Type)
+}
@@ -18,115 +18,126 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package config
 
 import (
+	"fmt"
 	"os"
-	"reflect"
+	"regexp"
 	"strings"
-
-	"github.com/iTrellis/common/formats"
 )
 
-func (p *AdapterConfig) copyDollarSymbol(key string, maps *map[string]interface{}) error {
-	tokens := []string{}
-	if key != "" {
-		tokens = append(tokens, key)
-	}
-	for k, v := range *maps {
-		if v == nil {
-			return nil
+// maxReferenceDepth max depth of references in references, exp: ${a} => ${b} => ${c}
+const maxReferenceDepth = 32
+
+var includeRegexp = regexp.MustCompile(includeReg)
+
+// copyDollarSymbol replace references ${X.Y.Z}, ${ENV} and ${X.Y.Z:-default} in p.configs:
+// a value of the reference only is replaced by the referenced value,
+// references in a longer string are interpolated, exp: "http://${host}:${port}"
+func (p *AdapterConfig) copyDollarSymbol() error {
+	_, err := p.resolveValue(p.configs, 0)
+	return err
+}
+
+func (p *AdapterConfig) resolveValue(value interface{}, depth int) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return p.expandString(v, depth)
+	case Options:
+		return p.resolveValue(map[string]interface{}(v), depth)
+	case map[string]interface{}:
+		for k, item := range v {
+			nv, err := p.resolveValue(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = nv
 		}
-		keys := append(tokens, k)
-		switch reflect.TypeOf(v).Kind() {
-		case reflect.Map:
-			{
-				vm, ok := v.(map[string]interface{})
-				if !ok {
-					continue
-				}
-				err := p.copyDollarSymbol(strings.Join(keys, "."), &vm)
-				if err != nil {
-					return err
-				}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			nv, err := p.resolveValue(item, depth)
+			if err != nil {
+				return nil, err
 			}
-		case reflect.String:
-			{
-				s, ok := v.(string)
-				if !ok {
-					continue
-				}
-				_, matched := formats.FindStringSubmatchMap(s, includeReg)
-				if !matched {
-					continue
-				}
-
-				pkey := s[2 : len(s)-1]
-				if p.EnvAllowed && (p.EnvPrefix == "" || strings.HasPrefix(pkey, p.EnvPrefix)) {
-					if env := os.Getenv(pkey); env != "" {
-						if err := p.setKeyValue(strings.Join(keys, "."), env); err != nil {
-							return err
-						}
-						continue
-					}
-				}
-
-				vm, err := p.getKeyValue(pkey)
-				if err != nil {
-					return err
-				}
-				err = p.setKeyValue(strings.Join(keys, "."), vm)
-				if err != nil {
-					return err
-				}
+			v[k] = nv
+		}
+	case []interface{}:
+		for i, item := range v {
+			nv, err := p.resolveValue(item, depth)
+			if err != nil {
+				return nil, err
 			}
+			v[i] = nv
 		}
 	}
-	return nil
+	return value, nil
 }
 
-func (p *AdapterConfig) getKeyValue(key string) (interface{}, error) {
+func (p *AdapterConfig) expandString(s string, depth int) (interface{}, error) {
+	matches := includeRegexp.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	if depth > maxReferenceDepth {
+		return nil, ErrCircularReference
+	}
+
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		return p.referenceValue(s, matches[0], depth)
+	}
 
-	tokens := strings.Split(key, ".")
-	vm := p.configs[tokens[0]]
-	for i, t := range tokens {
-		if i == 0 {
-			continue
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		v, err := p.referenceValue(s, m, depth)
+		if err != nil {
+			return nil, err
 		}
+		if v != nil {
+			b.WriteString(fmt.Sprint(v))
+		}
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// referenceValue return the value of the matched reference: ${name} or ${name:-default}
+func (p *AdapterConfig) referenceValue(s string, m []int, depth int) (interface{}, error) {
+	name, hasDefault := s[m[2]:m[3]], m[4] >= 0
 
-		switch v := vm.(type) {
-		case Options:
-			vm = v[t]
-		case map[string]interface{}:
-			vm = v[t]
-		case map[interface{}]interface{}:
-			vm = v[t]
-		default:
-			return nil, ErrNotMap
+	if p.EnvAllowed && (p.EnvPrefix == "" || strings.HasPrefix(name, p.EnvPrefix)) {
+		if env := os.Getenv(name); env != "" {
+			return env, nil
 		}
 	}
-	return vm, nil
+
+	v, err := p.getKeyValue(name)
+	if hasDefault && (err != nil || v == nil || v == "") {
+		return p.expandString(s[m[6]:m[7]], depth+1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p.resolveValue(v, depth+1)
+}
+
+func (p *AdapterConfig) getKeyValue(key string) (interface{}, error) {
+	tokens, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	vm, _, err := lookupTokens(p.configs, tokens)
+	return vm, err
 }
 
 // setKeyValue set key value into *configs
-func (p *AdapterConfig) setKeyValue(key string, value interface{}) (err error) {
-	tokens := strings.Split(key, ".")
-	for i := len(tokens) - 1; i >= 0; i-- {
-		if i == 0 {
-			p.configs[tokens[0]] = value
-			return
-		}
-		v, _ := p.getKeyValue(strings.Join(tokens[:i], "."))
-		switch vm := v.(type) {
-		case Options:
-			vm[tokens[i]] = value
-			value = vm
-		case map[string]interface{}:
-			vm[tokens[i]] = value
-			value = vm
-		case map[interface{}]interface{}:
-			vm[tokens[i]] = value
-			value = vm
-		default:
-			value = map[string]interface{}{tokens[i]: value}
-		}
+func (p *AdapterConfig) setKeyValue(key string, value interface{}) error {
+	tokens, err := parseKey(key)
+	if err != nil {
+		return err
 	}
-	return
+	_, err = setTokens(p.configs, tokens, value)
+	return err
 }
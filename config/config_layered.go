@@ -0,0 +1,363 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source a layer of configs
+type Source interface {
+	// name of the layer
+	Name() string
+	// load the configs of the layer
+	Load() (map[string]interface{}, error)
+}
+
+// LayeredConfig config merged by an ordered stack of sources,
+// later sources deep-merge over earlier ones
+type LayeredConfig struct {
+	*AdapterConfig
+
+	sources []Source
+	origins map[string]int
+}
+
+// NewLayeredConfig return a config merged by sources in order
+func NewLayeredConfig(sources ...Source) (*LayeredConfig, error) {
+	c := &LayeredConfig{
+		AdapterConfig: &AdapterConfig{
+			readerType: ReaderTypeYAML,
+			reader:     NewYAMLReader(),
+			configs:    make(map[string]interface{}),
+		},
+		sources: sources,
+	}
+
+	configs, origins, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	c.configs, c.origins = configs, origins
+	return c, nil
+}
+
+// Origin return the name of the source which supplied the key's value
+func (p *LayeredConfig) Origin(key string) (string, bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	key = normalizeKey(key)
+	if i, ok := p.origins[key]; ok {
+		return p.sources[i].Name(), true
+	}
+
+	// the key is a map, return the last layer which supplied values into it
+	found := -1
+	for k, i := range p.origins {
+		if strings.HasPrefix(k, key+".") && i > found {
+			found = i
+		}
+	}
+	if found < 0 {
+		return "", false
+	}
+	return p.sources[found].Name(), true
+}
+
+// Reload load all the sources again
+func (p *LayeredConfig) Reload() error {
+	configs, origins, err := p.load()
+	if err != nil {
+		return err
+	}
+
+	p.locker.Lock()
+	olds := p.configs
+	p.configs, p.origins = configs, origins
+	p.record("reload")
+	p.locker.Unlock()
+
+	p.notifyChanges(changedKeys("", olds, configs))
+	return nil
+}
+
+func (p *LayeredConfig) load() (map[string]interface{}, map[string]int, error) {
+	configs := make(map[string]interface{})
+	origins := make(map[string]int)
+	for i, s := range p.sources {
+		values, err := s.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+		mergeValues("", configs, values, func(key string, leaf bool) {
+			for k := range origins {
+				if k == key || strings.HasPrefix(k, key+".") {
+					delete(origins, k)
+				}
+			}
+			if leaf {
+				origins[key] = i
+			}
+		})
+	}
+
+	nc := &AdapterConfig{
+		EnvPrefix:  p.EnvPrefix,
+		EnvAllowed: p.EnvAllowed,
+		configs:    configs,
+	}
+	if err := nc.copyDollarSymbol(); err != nil {
+		return nil, nil, err
+	}
+	return nc.configs, origins, nil
+}
+
+// MergeValues deep merge src into dst, values in src take precedence
+func MergeValues(dst, src map[string]interface{}) {
+	mergeValues("", dst, src, nil)
+}
+
+// setFn is called when the key of dst is replaced, leaf is false if the new value is a map
+func mergeValues(prefix string, dst, src map[string]interface{}, setFn func(key string, leaf bool)) {
+	for k, sv := range src {
+		key := joinKey(prefix, k)
+		if sm, ok := toStringMap(sv); ok {
+			dm, ok := toStringMap(dst[k])
+			if !ok {
+				dm = make(map[string]interface{})
+				if setFn != nil {
+					setFn(key, false)
+				}
+			}
+			mergeValues(key, dm, sm, setFn)
+			dst[k] = dm
+			continue
+		}
+		dst[k] = DeepCopy(sv)
+		if setFn != nil {
+			setFn(key, true)
+		}
+	}
+}
+
+type fileSource struct {
+	filename string
+	optional bool
+}
+
+// NewFileSource return a source reading the file, judge reader by file's suffix;
+// an optional source is empty if the file does not exist
+func NewFileSource(filename string, optional ...bool) Source {
+	return &fileSource{
+		filename: filename,
+		optional: len(optional) > 0 && optional[0],
+	}
+}
+
+func (p *fileSource) Name() string {
+	return p.filename
+}
+
+func (p *fileSource) Load() (map[string]interface{}, error) {
+	if p.optional {
+		if _, err := os.Stat(p.filename); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	r, err := fileToReader(p.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := filesRepo.Read(p.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err = r.ParseData(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+type stringSource struct {
+	name string
+	rt   ReaderType
+	data string
+}
+
+// NewStringSource return a source parsing the string by the reader type
+func NewStringSource(name string, rt ReaderType, data string) Source {
+	return &stringSource{name: name, rt: rt, data: data}
+}
+
+func (p *stringSource) Name() string {
+	return p.name
+}
+
+func (p *stringSource) Load() (map[string]interface{}, error) {
+	r, err := NewReader(p.rt, "")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err = r.ParseData([]byte(p.data), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+type structSource struct {
+	name string
+	rt   ReaderType
+	st   interface{}
+}
+
+// NewStructSource return a source dumping the struct by the reader type, exp: defaults
+func NewStructSource(name string, rt ReaderType, st interface{}) Source {
+	return &structSource{name: name, rt: rt, st: st}
+}
+
+func (p *structSource) Name() string {
+	return p.name
+}
+
+func (p *structSource) Load() (map[string]interface{}, error) {
+	r, err := NewReader(p.rt, "")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.Dump(p.st)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err = r.ParseData(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+type optionsSource struct {
+	name string
+	opts Options
+}
+
+// NewOptionsSource return a source of key values, keys are dot separated, exp: a.b.c
+func NewOptionsSource(name string, opts Options) Source {
+	return &optionsSource{name: name, opts: opts}
+}
+
+func (p *optionsSource) Name() string {
+	return p.name
+}
+
+func (p *optionsSource) Load() (map[string]interface{}, error) {
+	keys := make([]string, 0, len(p.opts))
+	for k := range p.opts {
+		keys = append(keys, k)
+	}
+	// parents are set before children
+	sort.Strings(keys)
+
+	c := &AdapterConfig{configs: make(map[string]interface{})}
+	for _, k := range keys {
+		if err := c.setKeyValue(k, DeepCopy(p.opts[k])); err != nil {
+			return nil, err
+		}
+	}
+	return c.configs, nil
+}
+
+type envSource struct {
+	prefix string
+}
+
+// NewENVSource return a source of environment variables with the prefix,
+// exp: prefix APP, APP_DB_HOST => db.host, no variables are loaded without a prefix
+func NewENVSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+func (p *envSource) Name() string {
+	return "env"
+}
+
+func (p *envSource) Load() (map[string]interface{}, error) {
+	opts := Options{}
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if key, ok := envToKey(p.prefix, kv[0]); ok {
+			opts[key] = kv[1]
+		}
+	}
+	if err := checkENVKeys(opts); err != nil {
+		return nil, err
+	}
+	return NewOptionsSource(p.Name(), opts).Load()
+}
+
+// checkENVKeys return an error if a variable is the parent of another one, exp: APP_DB and APP_DB_HOST,
+// the value of the parent can not be a string and a map at the same time
+func checkENVKeys(opts Options) error {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for i := range key {
+			if key[i] != '.' {
+				continue
+			}
+			if _, ok := opts[key[:i]]; ok {
+				return fmt.Errorf("%w: %s and %s", ErrConflictingKeys, key[:i], key)
+			}
+		}
+	}
+	return nil
+}
+
+// envToKey convert environment variable name into dot separated key
+func envToKey(prefix, name string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "_")
+	if prefix == "" {
+		return "", false
+	}
+	prefix += "_"
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+	name = name[len(prefix):]
+	if name == "" {
+		return "", false
+	}
+	return strings.ToLower(strings.Replace(name, "_", ".", -1)), true
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+type layeredDefaults struct {
+	DB struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"db"`
+	Name string `yaml:"name"`
+}
+
+func TestLayeredConfig(t *testing.T) {
+	defaults := layeredDefaults{Name: "default"}
+	defaults.DB.Host, defaults.DB.Port = "localhost", 3306
+
+	err := os.Setenv("LAYERED_DB_HOST", "db.env")
+	testutils.Ok(t, err)
+	defer os.Unsetenv("LAYERED_DB_HOST")
+
+	c, err := config.NewLayeredConfig(
+		config.NewStructSource("defaults", config.ReaderTypeYAML, defaults),
+		config.NewFileSource(yamlFile),
+		config.NewFileSource("not_exist.yml", true),
+		config.NewStringSource("env.yml", config.ReaderTypeYAML, "db:\n  port: 3307\n  user: root\n"),
+		config.NewENVSource("LAYERED"),
+		config.NewOptionsSource("flags", config.Options{"name": "flag"}),
+	)
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "db.env", c.GetString("db.host"))
+	testutils.Equals(t, 3307, c.GetInt("db.port"))
+	testutils.Equals(t, "root", c.GetString("db.user"))
+	testutils.Equals(t, "flag", c.GetString("name"))
+	testutils.Equals(t, "Easy!", c.GetString("a"))
+	testutils.Equals(t, "test", c.GetString("b.c.cn.a"))
+
+	for key, origin := range map[string]string{
+		"db.host": "env",
+		"db.port": "env.yml",
+		"name":    "flags",
+		"a":       yamlFile,
+		"db":      "env",
+	} {
+		name, ok := c.Origin(key)
+		testutils.Assert(t, ok, "origin of %s should be found", key)
+		testutils.Equals(t, origin, name, key)
+	}
+
+	_, ok := c.Origin("xxx")
+	testutils.Assert(t, !ok, "origin of xxx should not be found")
+
+	_, err = config.NewLayeredConfig(config.NewFileSource("not_exist.yml"))
+	testutils.NotOk(t, err)
+}
+
+func TestENVSourceConflicts(t *testing.T) {
+	testutils.Ok(t, os.Setenv("CONFLICT_DB", "x"))
+	defer os.Unsetenv("CONFLICT_DB")
+	testutils.Ok(t, os.Setenv("CONFLICT_DB_HOST", "y"))
+	defer os.Unsetenv("CONFLICT_DB_HOST")
+
+	for i := 0; i < 10; i++ {
+		_, err := config.NewENVSource("CONFLICT").Load()
+		testutils.Assert(t, errors.Is(err, config.ErrConflictingKeys), "unexpected error: %v", err)
+		testutils.Equals(t, "conflicting keys of a value and its children: db and db.host", err.Error())
+	}
+
+	testutils.Ok(t, os.Unsetenv("CONFLICT_DB"))
+	values, err := config.NewENVSource("CONFLICT").Load()
+	testutils.Ok(t, err)
+	testutils.Equals(t, map[string]interface{}{"db": map[string]interface{}{"host": "y"}}, values)
+}
+
+func TestENVSourceEmptyPrefix(t *testing.T) {
+	testutils.Ok(t, os.Setenv("EMPTY_PREFIX_HOST", "x"))
+	defer os.Unsetenv("EMPTY_PREFIX_HOST")
+
+	for _, prefix := range []string{"", "_"} {
+		values, err := config.NewENVSource(prefix).Load()
+		testutils.Ok(t, err)
+		testutils.Equals(t, 0, len(values))
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"strings"
+)
+
+// OptionENVOverlay override keys by environment variables without references,
+// exp: prefix APP, APP_DB_HOST overrides db.host, new keys are added too;
+// the prefix must be set by OptionENVPrefix, the overlay is skipped without it,
+// because keys like user, path and home would be overridden by USER, PATH and HOME
+func OptionENVOverlay() OptionFunc {
+	return func(c *AdapterConfig) {
+		c.EnvOverlay = true
+	}
+}
+
+// OptionOverrides override keys by the key values, exp: {"db.host": "localhost"}
+func OptionOverrides(opts Options) OptionFunc {
+	return func(c *AdapterConfig) {
+		if c.overrides == nil {
+			c.overrides = Options{}
+		}
+		for k, v := range opts {
+			c.overrides[k] = v
+		}
+	}
+}
+
+// OptionFlagSet override keys by the flags which were set, exp: -db.host=localhost
+func OptionFlagSet(fs *flag.FlagSet) OptionFunc {
+	return OptionOverrides(FlagSetOptions(fs))
+}
+
+// OptionArgs override keys by the command line arguments, exp: --db.host=localhost
+func OptionArgs(args []string) OptionFunc {
+	return OptionOverrides(ArgsOptions(args))
+}
+
+// FlagSetOptions return the key values of the flags which were set
+func FlagSetOptions(fs *flag.FlagSet) Options {
+	opts := Options{}
+	fs.Visit(func(f *flag.Flag) {
+		if getter, ok := f.Value.(flag.Getter); ok {
+			opts[f.Name] = getter.Get()
+			return
+		}
+		opts[f.Name] = f.Value.String()
+	})
+	return opts
+}
+
+// ArgsOptions parse the key values of arguments: --key=value, --key value, --key (true)
+func ArgsOptions(args []string) Options {
+	opts := Options{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if name == "" {
+			continue
+		}
+		if j := strings.Index(name, "="); j >= 0 {
+			opts[name[:j]] = name[j+1:]
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			opts[name] = args[i+1]
+			i++
+			continue
+		}
+		opts[name] = "true"
+	}
+	return opts
+}
+
+// resolve overlay the parsed configs, replace references, then validate them
+func (p *AdapterConfig) resolve() error {
+	if p.configs == nil {
+		p.configs = make(map[string]interface{})
+	}
+	if err := p.overlay(); err != nil {
+		return err
+	}
+	if err := p.copyDollarSymbol(); err != nil {
+		return err
+	}
+	return p.validate()
+}
+
+func (p *AdapterConfig) overlay() error {
+	if p.EnvOverlay && p.EnvPrefix != "" {
+		if err := p.overlayENV(); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(p.overrides))
+	for k := range p.overrides {
+		keys = append(keys, k)
+	}
+	// parents are set before children
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := p.setKeyValue(k, DeepCopy(p.overrides[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *AdapterConfig) overlayENV() error {
+	envs := make(map[string]string)
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) == 2 {
+			envs[kv[0]] = kv[1]
+		}
+	}
+
+	for _, key := range leafKeys("", p.configs) {
+		name := keyToENV(p.EnvPrefix, key)
+		if v, ok := envs[name]; ok {
+			if err := p.setKeyValue(key, v); err != nil {
+				return err
+			}
+			delete(envs, name)
+		}
+	}
+
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if key, ok := envToKey(p.EnvPrefix, name); ok {
+			if err := p.setKeyValue(key, envs[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keyToENV convert dot separated key into environment variable name, exp: APP, db.host => APP_DB_HOST
+func keyToENV(prefix, key string) string {
+	name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
+
+// leafKeys return the sorted dot separated keys of values which are not maps
+func leafKeys(prefix string, values map[string]interface{}) []string {
+	var keys []string
+	for k, v := range values {
+		if m, ok := toStringMap(v); ok && len(m) > 0 {
+			keys = append(keys, leafKeys(joinKey(prefix, k), m)...)
+			continue
+		}
+		keys = append(keys, joinKey(prefix, k))
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+const overlayYAML = `
+db:
+  host: localhost
+  port: 3306
+  max_conns: 10
+  url: "mysql://${db.host}:${db.port}/${db.name:-test}"
+  user: ${DB_USER:-root}
+debug: false
+`
+
+func TestReferences(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, overlayYAML))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "mysql://localhost:3306/test", c.GetString("db.url"))
+	testutils.Equals(t, "root", c.GetString("db.user"))
+
+	_, err = config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, "a: ${b}\nb: x${a}\n"))
+	testutils.ErrorEqual(t, config.ErrCircularReference, err)
+}
+
+func TestENVOverlay(t *testing.T) {
+	for k, v := range map[string]string{
+		"OVERLAY_DB_HOST":      "db.local",
+		"OVERLAY_DB_MAX_CONNS": "20",
+		"OVERLAY_DEBUG":        "true",
+		"OVERLAY_CACHE_SIZE":   "1k",
+	} {
+		testutils.Ok(t, os.Setenv(k, v))
+		defer os.Unsetenv(k)
+	}
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, overlayYAML),
+		config.OptionENVOverlay(),
+		config.OptionENVPrefix("OVERLAY"),
+		config.OptionArgs([]string{"run", "--db.port=3307", "-db.name", "prod", "--verbose", "--", "--debug=false"}),
+	)
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "db.local", c.GetString("db.host"))
+	testutils.Equals(t, 20, c.GetInt("db.max_conns"))
+	testutils.Equals(t, true, c.GetBoolean("debug"))
+	testutils.Equals(t, "1k", c.GetString("cache.size"))
+	testutils.Equals(t, true, c.GetBoolean("verbose"))
+	testutils.Equals(t, "mysql://db.local:3307/prod", c.GetString("db.url"))
+
+	// ambient variables do not override keys without the prefix
+	user, hasUser := os.LookupEnv("USER")
+	testutils.Ok(t, os.Setenv("USER", "ambient"))
+	defer func() {
+		if hasUser {
+			os.Setenv("USER", user)
+			return
+		}
+		os.Unsetenv("USER")
+	}()
+	c, err = config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "user: root\n"),
+		config.OptionENVOverlay())
+	testutils.Ok(t, err)
+	testutils.Equals(t, "root", c.GetString("user"))
+}
+
+func TestFlagSetOverlay(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db.host", "", "db host")
+	fs.Int("db.port", 0, "db port")
+	fs.Bool("debug", false, "debug")
+	testutils.Ok(t, fs.Parse([]string{"-db.port", "3307"}))
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, overlayYAML),
+		config.OptionFlagSet(fs))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "localhost", c.GetString("db.host"))
+	testutils.Equals(t, 3307, c.GetInt("db.port"))
+	testutils.Equals(t, false, c.GetBoolean("debug"))
+}
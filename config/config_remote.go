@@ -0,0 +1,194 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/iTrellis/common/discovery"
+)
+
+type remoteSource struct {
+	client   discovery.Client
+	prefix   string
+	optional bool
+
+	locker sync.Mutex
+	loaded bool
+	values Options
+	// pending are the watched changes before the values are listed
+	pending []remoteChange
+}
+
+type remoteChange struct {
+	path  string
+	value interface{}
+}
+
+// NewRemoteSource return a source of the values under the prefix of discovery client,
+// paths are converted into dot separated keys, exp: prefix/db/host => db.host;
+// an optional source is empty if the client is unavailable
+func NewRemoteSource(client discovery.Client, prefix string, optional ...bool) Source {
+	return &remoteSource{
+		client:   client,
+		prefix:   prefix,
+		optional: len(optional) > 0 && optional[0],
+	}
+}
+
+func (p *remoteSource) Name() string {
+	return p.prefix
+}
+
+func (p *remoteSource) Load() (map[string]interface{}, error) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if !p.loaded {
+		values, err := p.list()
+		if err != nil {
+			if p.optional {
+				return nil, nil
+			}
+			return nil, err
+		}
+		p.values, p.loaded = values, true
+		// the changes may be not listed, they are applied in order, so the last one wins
+		for _, c := range p.pending {
+			p.set(c.path, c.value)
+		}
+		p.pending = nil
+	}
+
+	return NewOptionsSource(p.Name(), p.values).Load()
+}
+
+func (p *remoteSource) list() (Options, error) {
+	ctx := context.Background()
+	keys, err := p.client.List(ctx, p.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	values := Options{}
+	for _, k := range keys {
+		v, err := p.client.Get(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := p.toKey(k); ok && v != nil {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// update set the watched value, it is pending until values are listed
+func (p *remoteSource) update(path string, value interface{}) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if !p.loaded {
+		p.pending = append(p.pending, remoteChange{path: path, value: value})
+		return
+	}
+	p.set(path, value)
+}
+
+func (p *remoteSource) set(path string, value interface{}) {
+	key, ok := p.toKey(path)
+	if !ok {
+		return
+	}
+	// the deleted key is watched with nil value
+	if value == nil {
+		delete(p.values, key)
+		return
+	}
+	p.values[key] = value
+}
+
+func (p *remoteSource) toKey(path string) (string, bool) {
+	key := strings.Trim(strings.TrimPrefix(path, p.prefix), "/")
+	if key == "" {
+		return "", false
+	}
+	var k string
+	for _, name := range strings.Split(key, "/") {
+		k = joinKey(k, name)
+	}
+	return k, true
+}
+
+// RemoteConfig config loaded from the prefix of discovery client, changes are applied live
+type RemoteConfig struct {
+	*LayeredConfig
+
+	source *remoteSource
+	cancel context.CancelFunc
+}
+
+// NewRemoteConfig return a config loaded from the prefix of discovery client and watch it,
+// values of the fallback file are used if they are not in the remote or the remote is unavailable
+func NewRemoteConfig(client discovery.Client, prefix string, fallback ...string) (*RemoteConfig, error) {
+	source := &remoteSource{client: client, prefix: prefix}
+
+	var sources []Source
+	if len(fallback) > 0 && fallback[0] != "" {
+		source.optional = true
+		sources = append(sources, NewFileSource(fallback[0]))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &RemoteConfig{source: source, cancel: cancel}
+
+	// the watch is registered before listing, so no change is missed
+	started := make(chan struct{})
+	discovery.StartWatchPrefix(ctx, client, prefix, func(path string, value interface{}) bool {
+		source.update(path, value)
+
+		select {
+		case <-started:
+		case <-ctx.Done():
+			return false
+		}
+		// values are listed again when reloading if the remote was unavailable
+		if err := c.Reload(); err != nil {
+			c.watchError(err)
+		}
+		return ctx.Err() == nil
+	})
+
+	lc, err := NewLayeredConfig(append(sources, source)...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.LayeredConfig = lc
+	close(started)
+
+	return c, nil
+}
+
+// Close stop watching the discovery client
+func (p *RemoteConfig) Close() error {
+	p.cancel()
+	return p.LayeredConfig.Close()
+}
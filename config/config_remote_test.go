@@ -0,0 +1,77 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/codec"
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/discovery"
+	"github.com/iTrellis/common/testutils"
+)
+
+func putRemoteValue(t *testing.T, client discovery.Client, key, value string) {
+	err := client.CAS(context.Background(), key, func(interface{}) (interface{}, bool, error) {
+		return value, true, nil
+	})
+	testutils.Ok(t, err)
+}
+
+func TestRemoteConfig(t *testing.T) {
+	client := discovery.NewInMemoryClient(codec.String{})
+	putRemoteValue(t, client, "/app/config/db/host", "db.remote")
+	putRemoteValue(t, client, "/app/config/a", "Remote!")
+
+	c, err := config.NewRemoteConfig(client, "/app/config/", yamlFile)
+	testutils.Ok(t, err)
+	defer c.Close()
+
+	changes := make(chan []string, 1)
+	c.OnChange(func(keys []string) { changes <- keys })
+
+	testutils.Equals(t, "db.remote", c.GetString("db.host"))
+	testutils.Equals(t, "Remote!", c.GetString("a"))
+	testutils.Equals(t, "test", c.GetString("b.c.cn.a"))
+
+	origin, _ := c.Origin("db.host")
+	testutils.Equals(t, "/app/config/", origin)
+	origin, _ = c.Origin("b.c.e")
+	testutils.Equals(t, yamlFile, origin)
+
+	// the watch is registered when the config is returned
+	putRemoteValue(t, client, "/app/config/db/port", "3307")
+	select {
+	case keys := <-changes:
+		testutils.Equals(t, []string{"db.port"}, keys)
+		testutils.Equals(t, 3307, c.GetInt("db.port"))
+	case <-time.After(time.Second * 5):
+		t.Fatal("remote value was not applied")
+	}
+
+	testutils.Ok(t, client.Delete(context.Background(), "/app/config/db/port"))
+	select {
+	case keys := <-changes:
+		testutils.Equals(t, []string{"db.port"}, keys)
+		testutils.Equals(t, nil, c.GetInterface("db.port"))
+	case <-time.After(time.Second * 5):
+		t.Fatal("remote value was not removed")
+	}
+}
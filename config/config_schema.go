@@ -0,0 +1,207 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/iTrellis/common/errors"
+	"github.com/iTrellis/common/json"
+)
+
+// Schema subset of JSON Schema to validate configs
+type Schema struct {
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Enum       []interface{}      `json:"enum,omitempty"`
+	Minimum    *float64           `json:"minimum,omitempty"`
+	Maximum    *float64           `json:"maximum,omitempty"`
+	MinLength  *int               `json:"minLength,omitempty"`
+	MaxLength  *int               `json:"maxLength,omitempty"`
+	MinItems   *int               `json:"minItems,omitempty"`
+	MaxItems   *int               `json:"maxItems,omitempty"`
+	Pattern    string             `json:"pattern,omitempty"`
+	// Format email, url, ip, ipv4, ipv6, hostport, duration, bytesize
+	Format string `json:"format,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// NewSchemaValidator return a validator by JSON Schema,
+// supported: type, properties, required, items, enum, minimum, maximum,
+// minLength, maxLength, minItems, maxItems, pattern, format
+func NewSchemaValidator(data []byte) (Validator, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *Schema) compile() (err error) {
+	if p.Pattern != "" {
+		if p.pattern, err = regexp.Compile(p.Pattern); err != nil {
+			return err
+		}
+	}
+	for _, s := range p.Properties {
+		if err = s.compile(); err != nil {
+			return err
+		}
+	}
+	if p.Items != nil {
+		return p.Items.compile()
+	}
+	return nil
+}
+
+// Validate validate configs with the schema
+func (p *Schema) Validate(c Config) error {
+	return p.validate(nil, "", configValues(c)).Errors()
+}
+
+func (p *Schema) validate(errs errors.Errors, key string, value interface{}) errors.Errors {
+	if msg := p.checkType(value); msg != "" {
+		return errs.Append(&ValidationError{Key: key, Message: msg})
+	}
+
+	if len(p.Enum) > 0 {
+		found := false
+		for _, e := range p.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("must be one of %v", p.Enum)})
+		}
+	}
+
+	if f, ok := toFloat(value); ok && !isString(value) {
+		if p.Minimum != nil && f < *p.Minimum {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("must be >= %v", *p.Minimum)})
+		}
+		if p.Maximum != nil && f > *p.Maximum {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("must be <= %v", *p.Maximum)})
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		l := len([]rune(s))
+		if p.MinLength != nil && l < *p.MinLength {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("length must be >= %d", *p.MinLength)})
+		}
+		if p.MaxLength != nil && l > *p.MaxLength {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("length must be <= %d", *p.MaxLength)})
+		}
+		if p.pattern != nil && !p.pattern.MatchString(s) {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("does not match pattern %s", p.Pattern)})
+		}
+		if p.Format != "" && !checkFormat(p.Format, s) {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("invalid format %s", p.Format)})
+		}
+	}
+
+	if values, ok := toStringMap(value); ok {
+		for _, name := range p.Required {
+			if v, ok := values[name]; !ok || v == nil {
+				errs = errs.Append(&ValidationError{Key: joinKey(key, name), Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(p.Properties))
+		for name := range p.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v, ok := values[name]
+			if !ok || v == nil {
+				continue
+			}
+			errs = p.Properties[name].validate(errs, joinKey(key, name), v)
+		}
+	}
+
+	if vs := reflect.ValueOf(value); vs.Kind() == reflect.Slice || vs.Kind() == reflect.Array {
+		if p.MinItems != nil && vs.Len() < *p.MinItems {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("items must be >= %d", *p.MinItems)})
+		}
+		if p.MaxItems != nil && vs.Len() > *p.MaxItems {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("items must be <= %d", *p.MaxItems)})
+		}
+		if p.Items != nil {
+			for i := 0; i < vs.Len(); i++ {
+				errs = p.Items.validate(errs, indexKey(key, i), vs.Index(i).Interface())
+			}
+		}
+	}
+	return errs
+}
+
+func (p *Schema) checkType(value interface{}) string {
+	switch p.Type {
+	case "":
+		return ""
+	case "object":
+		if _, ok := toStringMap(value); ok {
+			return ""
+		}
+	case "array":
+		if vs := reflect.ValueOf(value); vs.Kind() == reflect.Slice || vs.Kind() == reflect.Array {
+			return ""
+		}
+	case "string":
+		if isString(value) {
+			return ""
+		}
+	case "integer":
+		if f, ok := toFloat(value); ok && !isString(value) && f == math.Trunc(f) {
+			return ""
+		}
+	case "number":
+		if _, ok := toFloat(value); ok && !isString(value) {
+			return ""
+		}
+	case "boolean":
+		if _, ok := toBool(value); ok {
+			return ""
+		}
+	default:
+		return fmt.Sprintf("unknown schema type %s", p.Type)
+	}
+	return fmt.Sprintf("invalid type, expected %s", p.Type)
+}
+
+// isString judge value is a string but not a json.Number
+func isString(value interface{}) bool {
+	if _, ok := value.(json.Number); ok {
+		return false
+	}
+	return reflect.ValueOf(value).Kind() == reflect.String
+}
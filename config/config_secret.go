@@ -0,0 +1,182 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"crypto/aes"
+	"os"
+	"regexp"
+
+	"github.com/iTrellis/common/encryption/binary-formats"
+	"github.com/iTrellis/common/encryption/rijndael"
+)
+
+const encryptedReg = `ENC\(([0-9a-zA-Z+/=]*)\)`
+
+var encryptedRegexp = regexp.MustCompile(encryptedReg)
+
+// KeyProvider provide the AES key (16, 24 or 32 bytes) of encrypted values: ENC(base64...)
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyProviderFunc function to provide the key
+type KeyProviderFunc func() ([]byte, error)
+
+// Key return the key
+func (f KeyProviderFunc) Key() ([]byte, error) {
+	return f()
+}
+
+// StaticKey return a key provider with the key
+func StaticKey(key []byte) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		return key, nil
+	})
+}
+
+// ENVKey return a key provider reading the key from environment variable
+func ENVKey(name string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		key := os.Getenv(name)
+		if key == "" {
+			return nil, ErrSecretKeyNotFound
+		}
+		return []byte(key), nil
+	})
+}
+
+// OptionKeyProvider decrypt values ENC(base64...) with the key provider when they are got
+func OptionKeyProvider(kp KeyProvider) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.keyProvider = kp
+	}
+}
+
+// EncryptValue encrypt the plain text into ENC(base64...) to paste into config files
+func EncryptValue(key []byte, plain string) (string, error) {
+	bs, err := rijndael.AESECBPKCSEncrypt(key, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	return "ENC(" + bsf.Encode(bsf.EncodeStd, bs) + ")", nil
+}
+
+// DecryptValue decrypt all the ENC(base64...) in the value
+func DecryptValue(key []byte, value string) (string, error) {
+	var err error
+	s := encryptedRegexp.ReplaceAllStringFunc(value, func(m string) string {
+		if err != nil {
+			return m
+		}
+
+		var bs []byte
+		bs, err = bsf.DecodeString(bsf.EncodeStd, encryptedRegexp.FindStringSubmatch(m)[1])
+		if err != nil {
+			return m
+		}
+		// the ciphertext is not checked by the decrypter, so check it to get an error but not garbage
+		if len(bs) == 0 || len(bs)%aes.BlockSize != 0 {
+			err = ErrInvalidEncryptedValue
+			return m
+		}
+		if bs, err = rijndael.AESECBPKCSDecrypt(key, bs); err != nil {
+			return m
+		}
+		return string(bs)
+	})
+	if err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// IsEncryptedValue judge the value contains ENC(base64...)
+func IsEncryptedValue(value string) bool {
+	return encryptedRegexp.MatchString(value)
+}
+
+// decryptValue return the value with decrypted strings, maps and lists are copied if they are decrypted
+func (p *AdapterConfig) decryptValue(value interface{}) (interface{}, error) {
+	if p.keyProvider == nil || !hasEncryptedValue(value) {
+		return value, nil
+	}
+
+	key, err := p.keyProvider.Key()
+	if err != nil {
+		return nil, err
+	}
+	return decryptValue(key, DeepCopy(value))
+}
+
+func decryptValue(key []byte, value interface{}) (interface{}, error) {
+	var err error
+	switch v := value.(type) {
+	case string:
+		return DecryptValue(key, v)
+	case Options:
+		return decryptValue(key, map[string]interface{}(v))
+	case map[string]interface{}:
+		for k, item := range v {
+			if v[k], err = decryptValue(key, item); err != nil {
+				return nil, err
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			if v[k], err = decryptValue(key, item); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if v[i], err = decryptValue(key, item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+func hasEncryptedValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return IsEncryptedValue(v)
+	case Options:
+		return hasEncryptedValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestEncryptedValues(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	enc, err := config.EncryptValue(key, "p@ssw0rd")
+	testutils.Ok(t, err)
+	testutils.Assert(t, config.IsEncryptedValue(enc), "%s should be encrypted", enc)
+
+	plain, err := config.DecryptValue(key, enc)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "p@ssw0rd", plain)
+
+	testutils.Ok(t, os.Setenv("CONFIG_SECRET_KEY", string(key)))
+	defer os.Unsetenv("CONFIG_SECRET_KEY")
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, `
+db:
+  password: `+enc+`
+  url: "root:${db.password}@localhost"
+  passwords: [`+enc+`]
+`),
+		config.OptionKeyProvider(config.ENVKey("CONFIG_SECRET_KEY")))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "p@ssw0rd", c.GetString("db.password"))
+	testutils.Equals(t, "root:p@ssw0rd@localhost", c.GetString("db.url"))
+	testutils.Equals(t, []string{"p@ssw0rd"}, c.GetStringList("db.passwords"))
+	testutils.Equals(t, "p@ssw0rd", c.GetMap("db")["password"])
+	testutils.Equals(t, "p@ssw0rd", c.Copy().GetString("db.password"))
+
+	var db struct {
+		Password string `yaml:"password"`
+	}
+	testutils.Ok(t, c.ToObject("db", &db))
+	testutils.Equals(t, "p@ssw0rd", db.Password)
+
+	bs, err := c.Dump()
+	testutils.Ok(t, err)
+	testutils.Assert(t, config.IsEncryptedValue(string(bs)), "dumped values should be encrypted")
+
+	c, err = config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "password: "+enc),
+		config.OptionKeyProvider(config.StaticKey([]byte("fedcba9876543210"))))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "default", c.GetString("password", "default"))
+}
+
+func TestInvalidEncryptedValues(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	for _, value := range []string{"ENC()", "ENC(QUJD)", "ENC(MDEyMzQ1Njc4OWFiY2RlZg==)"} {
+		_, err := config.DecryptValue(key, value)
+		testutils.NotOk(t, err)
+	}
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "password: ENC(QUJD)"),
+		config.OptionKeyProvider(config.StaticKey(key)))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "default", c.GetString("password", "default"))
+}
+
+type passwordValidator string
+
+func (p passwordValidator) Validate(c config.Config) error {
+	if password := c.GetString("db.password"); password != string(p) {
+		return &config.ValidationError{Key: "db.password", Message: "unexpected " + password}
+	}
+	return nil
+}
+
+func TestEncryptedValuesReload(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	enc, err := config.EncryptValue(key, "p@ssw0rd")
+	testutils.Ok(t, err)
+
+	dir, err := ioutil.TempDir("", "config_secret")
+	testutils.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "secret.yml")
+	testutils.Ok(t, ioutil.WriteFile(name, []byte("db:\n  password: "+enc+"\n"), 0644))
+
+	c, err := config.NewConfigOptions(
+		config.OptionFile(name),
+		config.OptionKeyProvider(config.StaticKey(key)),
+		config.OptionValidator(passwordValidator("p@ssw0rd")))
+	testutils.Ok(t, err)
+
+	testutils.Ok(t, ioutil.WriteFile(name, []byte("db:\n  password: "+enc+"\n  port: 3306\n"), 0644))
+	testutils.Ok(t, c.(*config.AdapterConfig).Reload())
+	testutils.Equals(t, "p@ssw0rd", c.GetString("db.password"))
+	testutils.Equals(t, 3306, c.GetInt("db.port"))
+}
+
+func TestEncryptedValuesInOptions(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	enc, err := config.EncryptValue(key, "p@ssw0rd")
+	testutils.Ok(t, err)
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "name: app"),
+		config.OptionKeyProvider(config.StaticKey(key)))
+	testutils.Ok(t, err)
+
+	testutils.Ok(t, c.SetKeyValue("db", config.Options{"password": enc}))
+	testutils.Equals(t, "p@ssw0rd", c.GetString("db.password"))
+	testutils.Equals(t, "p@ssw0rd", c.GetMap("db")["password"])
+}
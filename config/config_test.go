@@ -31,6 +31,9 @@ import (
 const (
 	jsonFile  = "example.json"
 	yamlFile  = "example.yml"
+	xmlFile   = "example.xml"
+	tomlFile  = "example.toml"
+	iniFile   = "example.ini"
 	wrongFile = "wrong_file"
 )
 
@@ -59,7 +62,7 @@ func TestNewJSONConfig(t *testing.T) {
 
 	testutils.Assert(t, faceList[0] == json.Number("3"), "b.d[0] should be json.Number `3`")
 	testutils.Assert(t, faceList[1] == json.Number("4"), "b.d[1] should be json.Number `4`")
-	testFunc(t, c)
+	testFunc(t, c, true)
 
 }
 
@@ -81,10 +84,110 @@ func TestNewYAMLConfig(t *testing.T) {
 	testutils.Assert(t, faceList[0] == 3, "b.d[0] should be `3`")
 	testutils.Assert(t, faceList[1] == 4, "b.d[1] should be `4`")
 
-	testFunc(t, c)
+	testFunc(t, c, true)
 }
 
-func testFunc(t *testing.T, c config.Config) {
+func TestNewXMLConfig(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionFile(xmlFile),
+		config.OptionENVAllowed(),
+		config.OptionENVPrefix("PRE"))
+	testutils.Ok(t, err)
+	testutils.Assert(t, c != nil, "loaded config should not be nil")
+	testutils.Equals(t, []int{3, 4}, c.GetIntList("b.d"))
+
+	testFunc(t, c, false)
+}
+
+func TestNewTOMLConfig(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionFile(tomlFile),
+		config.OptionENVAllowed(),
+		config.OptionENVPrefix("PRE"))
+	testutils.Ok(t, err)
+	testutils.Assert(t, c != nil, "loaded config should not be nil")
+	testutils.Equals(t, []int{3, 4}, c.GetIntList("b.d"))
+
+	testFunc(t, c, true)
+}
+
+func TestNewINIConfig(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionFile(iniFile),
+		config.OptionENVAllowed(),
+		config.OptionENVPrefix("PRE"))
+	testutils.Ok(t, err)
+	testutils.Assert(t, c != nil, "loaded config should not be nil")
+	testutils.Equals(t, []int{3, 4}, c.GetIntList("b.d"))
+
+	testFunc(t, c, false)
+}
+
+func TestDumpReaders(t *testing.T) {
+	type server struct {
+		Host  string   `yaml:"host" toml:"host" xml:"host,attr"`
+		Port  int      `yaml:"port" toml:"port" xml:"port"`
+		Debug bool     `yaml:"debug" toml:"debug" xml:"debug"`
+		Tags  []string `yaml:"tags" toml:"tags" xml:"tags"`
+	}
+	type model struct {
+		Name   string `yaml:"name" toml:"name" xml:"name"`
+		Server server `yaml:"server" toml:"server" xml:"server"`
+	}
+	origin := model{Name: "1; x", Server: server{Host: "localhost", Port: 80, Debug: true, Tags: []string{"a", "10"}}}
+
+	for _, rt := range []config.ReaderType{config.ReaderTypeXML, config.ReaderTypeTOML, config.ReaderTypeINI} {
+		c, err := config.NewConfigOptions(config.OptionStruct(rt, origin))
+		testutils.Ok(t, err)
+		testutils.Equals(t, 80, c.GetInt("server.port"))
+		testutils.Equals(t, []string{"a", "10"}, c.GetStringList("server.tags"))
+
+		bs, err := c.Dump()
+		testutils.Ok(t, err)
+
+		nc, err := config.NewConfigOptions(config.OptionString(rt, string(bs)))
+		testutils.Ok(t, err)
+
+		var m model
+		testutils.Ok(t, nc.ToObject("", &m))
+		testutils.Equals(t, origin, m)
+	}
+}
+
+func TestXMLScalars(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeXML,
+		`<config><port>80</port><code>010</code><debug>true</debug><tags>a</tags><tags>10</tags></config>`))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, 80, c.GetInt("port"))
+	testutils.Equals(t, "80", c.GetString("port"))
+	testutils.Equals(t, "010", c.GetInterface("code"))
+	testutils.Equals(t, true, c.GetBoolean("debug"))
+	testutils.Equals(t, []string{"a", "10"}, c.GetStringList("tags"))
+}
+
+func TestINIInlineComments(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeINI, `
+a = "a b" ; note
+b = 'x;y' # note
+c = it's ; note
+d = "a ; b"
+e = 1 ; note
+f = "a\"b" ; "note"
+`))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "a b", c.GetString("a"))
+	testutils.Equals(t, "x;y", c.GetString("b"))
+	testutils.Equals(t, "it's", c.GetString("c"))
+	testutils.Equals(t, "a ; b", c.GetString("d"))
+	testutils.Equals(t, 1, c.GetInt("e"))
+	testutils.Equals(t, `a"b`, c.GetString("f"))
+}
+
+// testFunc tests the config of example files, typed is false if the format has no types (xml, ini),
+// whose scalars are got as strings too
+func testFunc(t *testing.T, c config.Config, typed bool) {
 	testutils.Assert(t, c.GetString("b.u") == "", "env user should be empty")
 
 	envUser := os.Getenv("PRE_USER")
@@ -145,7 +248,11 @@ func testFunc(t *testing.T, c config.Config) {
 	testutils.Assert(t, hb.Int64() == 10995116277760, "h.b should equals 10995116277760")
 	testutils.Assert(t, c.GetString("b.d", "example") == "example", "b.d should be default example")
 	testutils.Assert(t, c.GetList("b.d") != nil, "b.d should not be nil")
-	testutils.Assert(t, c.GetStringList("b.d") == nil, "string list of b.d should be nil")
+	if typed {
+		testutils.Assert(t, c.GetStringList("b.d") == nil, "string list of b.d should be nil")
+	} else {
+		testutils.Equals(t, []string{"1", "2", "3", "4"}, c.GetStringList("b.d"))
+	}
 	testutils.Assert(t, c.GetIntList("b.d") != nil, "int list of b.d should not be nil")
 
 	c.SetKeyValue("b.d", []string{"1", "2", "3"})
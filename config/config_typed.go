@@ -0,0 +1,457 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iTrellis/common/formats"
+	"github.com/iTrellis/common/json"
+)
+
+// timeLayouts layouts to parse time, RFC3339 first
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	formats.DateTime,
+	formats.Date,
+	formats.DashTime,
+	formats.ZDate,
+	formats.ChineseDateTime,
+	formats.ChineseDate,
+	formats.ChineseZDateTime,
+	formats.ChineseZDate,
+	http.TimeFormat,
+}
+
+// KeyError the value of key is not found or can not be converted
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (p *KeyError) Error() string {
+	return fmt.Sprintf("%s: %s", p.Key, p.Err.Error())
+}
+
+// Unwrap return the cause
+func (p *KeyError) Unwrap() error {
+	return p.Err
+}
+
+// Get return the value of key converted into T, or an error if the key is not found or can not be converted.
+// Supported: string, bool, int, int32, int64, uint, uint32, uint64, float32, float64,
+// time.Duration, time.Time, *big.Int (byte size), []interface{}, []string, []bool, []int, []int64,
+// []float64, []time.Duration, map[string]interface{}, map[string]string, Options;
+// other types are unmarshaled by Config.ToObject
+func Get[T any](c Config, key string) (T, error) {
+	var t T
+	if key == "" {
+		return t, ErrInvalidKey
+	}
+
+	v, ok, err := lookupConfigValue(c, key)
+	if err != nil {
+		return t, &KeyError{Key: key, Err: err}
+	}
+	if !ok || v == nil {
+		return t, &KeyError{Key: key, Err: ErrKeyNotFound}
+	}
+
+	switch ptr := any(&t).(type) {
+	case *string:
+		*ptr, err = castString(v)
+	case *bool:
+		*ptr, err = castBool(v)
+	case *int:
+		var i int64
+		i, err = castIntN(v, strconv.IntSize)
+		*ptr = int(i)
+	case *int32:
+		var i int64
+		i, err = castIntN(v, 32)
+		*ptr = int32(i)
+	case *int64:
+		*ptr, err = castInt64(v)
+	case *uint:
+		var i uint64
+		i, err = castUintN(v, strconv.IntSize)
+		*ptr = uint(i)
+	case *uint32:
+		var i uint64
+		i, err = castUintN(v, 32)
+		*ptr = uint32(i)
+	case *uint64:
+		*ptr, err = castUint64(v)
+	case *float32:
+		var f float64
+		f, err = castFloat64(v)
+		*ptr = float32(f)
+	case *float64:
+		*ptr, err = castFloat64(v)
+	case *time.Duration:
+		*ptr, err = castDuration(v)
+	case *time.Time:
+		*ptr, err = castTime(v)
+	case **big.Int:
+		*ptr, err = castByteSize(v)
+	case *[]interface{}:
+		*ptr, err = castList(v)
+	case *[]string:
+		*ptr, err = castListOf(v, castString)
+	case *[]bool:
+		*ptr, err = castListOf(v, castBool)
+	case *[]int:
+		*ptr, err = castListOf(v, func(item interface{}) (int, error) {
+			i, err := castIntN(item, strconv.IntSize)
+			return int(i), err
+		})
+	case *[]int64:
+		*ptr, err = castListOf(v, castInt64)
+	case *[]float64:
+		*ptr, err = castListOf(v, castFloat64)
+	case *[]time.Duration:
+		*ptr, err = castListOf(v, castDuration)
+	case *map[string]interface{}:
+		*ptr, err = castStringMap(v)
+	case *Options:
+		*ptr, err = castStringMap(v)
+	case *map[string]string:
+		*ptr, err = castStringMapString(v)
+	default:
+		err = c.ToObject(key, &t)
+	}
+	if err != nil {
+		return t, &KeyError{Key: key, Err: err}
+	}
+	return t, nil
+}
+
+// GetInt64 return a int64 object in p.configs by key
+func (p *AdapterConfig) GetInt64(key string, defValue ...int64) int64 {
+	v, err := castInt64(p.GetInterface(key))
+	if err != nil {
+		if len(defValue) == 0 {
+			return 0
+		}
+		return defValue[0]
+	}
+	return v
+}
+
+// GetUint return a uint object in p.configs by key
+func (p *AdapterConfig) GetUint(key string, defValue ...uint) uint {
+	v, err := castUintN(p.GetInterface(key), strconv.IntSize)
+	if err != nil {
+		if len(defValue) == 0 {
+			return 0
+		}
+		return defValue[0]
+	}
+	return uint(v)
+}
+
+// GetTime return a time object in p.configs by key, exp: RFC3339, 2006-01-02 15:04:05, 2006-01-02
+func (p *AdapterConfig) GetTime(key string, defValue ...time.Time) time.Time {
+	v, err := castTime(p.GetInterface(key))
+	if err != nil {
+		if len(defValue) == 0 {
+			return time.Time{}
+		}
+		return defValue[0]
+	}
+	return v
+}
+
+// GetStringMap return a map in p.configs by key
+func (p *AdapterConfig) GetStringMap(key string) map[string]interface{} {
+	v, err := castStringMap(p.GetInterface(key))
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// GetStringMapString return a map of strings in p.configs by key
+func (p *AdapterConfig) GetStringMapString(key string) map[string]string {
+	v, err := castStringMapString(p.GetInterface(key))
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// GetDurationList return a list of time durations in p.configs by key
+func (p *AdapterConfig) GetDurationList(key string) []time.Duration {
+	v, err := castListOf(p.GetInterface(key), castDuration)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// lookup return the decrypted value of key, and whether the key is found
+func (p *AdapterConfig) lookup(key string) (interface{}, bool, error) {
+	p.locker.RLock()
+	v, ok := lookupValue(p.configs, key)
+	p.locker.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	v, err := p.decryptValue(v)
+	if err != nil {
+		return nil, true, err
+	}
+	return v, true, nil
+}
+
+func lookupConfigValue(c Config, key string) (interface{}, bool, error) {
+	if l, ok := c.(interface {
+		lookup(string) (interface{}, bool, error)
+	}); ok {
+		return l.lookup(key)
+	}
+	v := c.GetInterface(key)
+	return v, v != nil, nil
+}
+
+func castString(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case nil:
+		return "", ErrKeyNotFound
+	}
+
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		return "", fmt.Errorf("can not convert %T to string", v)
+	}
+	return fmt.Sprint(v), nil
+}
+
+func castBool(v interface{}) (bool, error) {
+	b, ok := toBool(v)
+	if !ok {
+		return false, fmt.Errorf("can not convert %v to bool", v)
+	}
+	return b, nil
+}
+
+func castInt64(v interface{}) (int64, error) {
+	return castIntN(v, 64)
+}
+
+func castIntN(v interface{}, bitSize int) (int64, error) {
+	if v == nil {
+		return 0, ErrKeyNotFound
+	}
+
+	var i int64
+	vt := reflect.ValueOf(v)
+	switch vt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = vt.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := vt.Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int%d", u, bitSize)
+		}
+		i = int64(u)
+	case reflect.Float32, reflect.Float64:
+		f := vt.Float()
+		if f != math.Trunc(f) || f > math.MaxInt64 || f < math.MinInt64 {
+			return 0, fmt.Errorf("can not convert %v to int%d", f, bitSize)
+		}
+		i = int64(f)
+	case reflect.String:
+		return strconv.ParseInt(strings.TrimSpace(vt.String()), 10, bitSize)
+	default:
+		return 0, fmt.Errorf("can not convert %T to int%d", v, bitSize)
+	}
+
+	if bitSize < 64 && (i > 1<<(bitSize-1)-1 || i < -1<<(bitSize-1)) {
+		return 0, fmt.Errorf("%d overflows int%d", i, bitSize)
+	}
+	return i, nil
+}
+
+func castUint64(v interface{}) (uint64, error) {
+	return castUintN(v, 64)
+}
+
+func castUintN(v interface{}, bitSize int) (uint64, error) {
+	if v == nil {
+		return 0, ErrKeyNotFound
+	}
+
+	vt := reflect.ValueOf(v)
+	switch vt.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := vt.Uint()
+		if bitSize < 64 && u > 1<<uint(bitSize)-1 {
+			return 0, fmt.Errorf("%d overflows uint%d", u, bitSize)
+		}
+		return u, nil
+	case reflect.String:
+		return strconv.ParseUint(strings.TrimSpace(vt.String()), 10, bitSize)
+	}
+
+	i, err := castInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || (bitSize < 64 && uint64(i) > 1<<uint(bitSize)-1) {
+		return 0, fmt.Errorf("%d overflows uint%d", i, bitSize)
+	}
+	return uint64(i), nil
+}
+
+func castFloat64(v interface{}) (float64, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("can not convert %v to float64", v)
+	}
+	return f, nil
+}
+
+func castDuration(v interface{}) (time.Duration, error) {
+	d, ok := toDuration(v)
+	if !ok {
+		return 0, fmt.Errorf("can not convert %v to duration", v)
+	}
+	return d, nil
+}
+
+func castTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		s := strings.TrimSpace(t)
+		for _, layout := range timeLayouts {
+			var (
+				tm  time.Time
+				err error
+			)
+			switch layout {
+			case time.RFC3339Nano, time.RFC3339, http.TimeFormat:
+				tm, err = time.Parse(layout, s)
+			default:
+				tm, err = formats.ParseLayoutTime(layout, s)
+			}
+			if err == nil {
+				return tm, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("can not convert %s to time", t)
+	case json.Number:
+		i, err := t.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return formats.UnixToTime(i), nil
+	}
+
+	i, err := castInt64(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("can not convert %v to time", v)
+	}
+	return formats.UnixToTime(i), nil
+}
+
+func castByteSize(v interface{}) (*big.Int, error) {
+	s, err := castString(v)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return i, nil
+	}
+	size := formats.ParseStringByteSize(strings.ToLower(s))
+	if size == nil {
+		return nil, fmt.Errorf("can not convert %s to byte size", s)
+	}
+	return size, nil
+}
+
+func castList(v interface{}) ([]interface{}, error) {
+	vs := reflect.ValueOf(v)
+	if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+		return nil, fmt.Errorf("can not convert %T to list", v)
+	}
+
+	items := make([]interface{}, 0, vs.Len())
+	for i := 0; i < vs.Len(); i++ {
+		items = append(items, vs.Index(i).Interface())
+	}
+	return items, nil
+}
+
+func castListOf[T any](v interface{}, fn func(interface{}) (T, error)) ([]T, error) {
+	list, err := castList(v)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, len(list))
+	for i, item := range list {
+		t, err := fn(item)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %s", i, err.Error())
+		}
+		items = append(items, t)
+	}
+	return items, nil
+}
+
+func castStringMap(v interface{}) (map[string]interface{}, error) {
+	m, ok := toStringMap(v)
+	if !ok {
+		return nil, ErrNotMap
+	}
+	return m, nil
+}
+
+func castStringMapString(v interface{}) (map[string]string, error) {
+	m, err := castStringMap(v)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(m))
+	for k, item := range m {
+		s, err := castString(item)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %s", k, err.Error())
+		}
+		result[k] = s
+	}
+	return result, nil
+}
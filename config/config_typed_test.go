@@ -0,0 +1,97 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+const typedYAML = `
+int64: 9007199254740993
+uint: 8
+negative: -1
+zero: 0
+float: 1.5
+time: 2021-10-01T10:00:00Z
+date: 2021-10-01
+timeout: 1m30s
+size: 1k
+durations: [1s, 2m, 1day]
+labels:
+  app: demo
+  port: 80
+server:
+  host: localhost
+  port: 80
+`
+
+func TestTypedGetters(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, typedYAML))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, int64(9007199254740993), c.GetInt64("int64"))
+	testutils.Equals(t, uint(8), c.GetUint("uint"))
+	testutils.Equals(t, uint(1), c.GetUint("negative", 1))
+	testutils.Equals(t, time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC), c.GetTime("time"))
+	testutils.Equals(t, 2021, c.GetTime("date").Year())
+	testutils.Equals(t, map[string]string{"app": "demo", "port": "80"}, c.GetStringMapString("labels"))
+	testutils.Equals(t, "demo", c.GetStringMap("labels")["app"])
+	testutils.Equals(t, []time.Duration{time.Second, time.Minute * 2, time.Hour * 24}, c.GetDurationList("durations"))
+
+	i, err := config.Get[int](c, "zero")
+	testutils.Ok(t, err)
+	testutils.Equals(t, 0, i)
+
+	_, err = config.Get[int](c, "missing")
+	testutils.Assert(t, errors.Is(err, config.ErrKeyNotFound), "missing should be not found: %v", err)
+
+	_, err = config.Get[int](c, "float")
+	testutils.NotOk(t, err)
+	_, err = config.Get[uint](c, "negative")
+	testutils.NotOk(t, err)
+
+	f, err := config.Get[float64](c, "float")
+	testutils.Ok(t, err)
+	testutils.Equals(t, 1.5, f)
+
+	d, err := config.Get[time.Duration](c, "timeout")
+	testutils.Ok(t, err)
+	testutils.Equals(t, time.Second*90, d)
+
+	size, err := config.Get[*big.Int](c, "size")
+	testutils.Ok(t, err)
+	testutils.Equals(t, int64(1024), size.Int64())
+
+	ds, err := config.Get[[]time.Duration](c, "durations")
+	testutils.Ok(t, err)
+	testutils.Equals(t, 3, len(ds))
+
+	type server struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	s, err := config.Get[server](c, "server")
+	testutils.Ok(t, err)
+	testutils.Equals(t, server{Host: "localhost", Port: 80}, s)
+}
@@ -0,0 +1,465 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iTrellis/common/errors"
+	"github.com/iTrellis/common/formats"
+	"github.com/iTrellis/common/json"
+)
+
+const validateTag = "validate"
+
+var (
+	emailReg = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Validator validate configs
+type Validator interface {
+	Validate(c Config) error
+}
+
+// ValidationError the value of key is invalid
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (p *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", p.Key, p.Message)
+}
+
+// OptionValidator validate configs when they are loaded or reloaded
+func OptionValidator(v Validator) OptionFunc {
+	return func(c *AdapterConfig) {
+		if v != nil {
+			c.validators = append(c.validators, v)
+		}
+	}
+}
+
+// Bind validate configs of the key by model's tags, then unmarshal them into the model
+func Bind(c Config, key string, model interface{}) error {
+	if err := NewStructValidator(model, key).Validate(c); err != nil {
+		return err
+	}
+	return c.ToObject(key, model)
+}
+
+func (p *AdapterConfig) validate() error {
+	var errs errors.Errors
+	for _, v := range p.validators {
+		if err := v.Validate(p); err != nil {
+			if es, ok := err.(errors.Errors); ok {
+				errs = errs.Append(es...)
+				continue
+			}
+			errs = errs.Append(err)
+		}
+	}
+	return errs.Errors()
+}
+
+type structValidator struct {
+	key string
+	typ reflect.Type
+}
+
+// NewStructValidator return a validator by model's tag `validate`, key is the model's path in configs
+//
+//	type Server struct {
+//		Host string        `yaml:"host" validate:"required,format=hostport"`
+//		Mode string        `yaml:"mode" validate:"enum=debug|release"`
+//		Port int           `yaml:"port" validate:"min=1,max=65535"`
+//		Name string        `yaml:"name" validate:"pattern=^[a-z]+$"`
+//		Wait time.Duration `yaml:"wait"`
+//	}
+//
+// formats: email, url, ip, hostport, duration, bytesize; pattern must be the last rule
+func NewStructValidator(model interface{}, key ...string) Validator {
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	v := &structValidator{typ: typ}
+	if len(key) > 0 {
+		v.key = key[0]
+	}
+	return v
+}
+
+func (p *structValidator) Validate(c Config) error {
+	var value interface{} = configValues(c)
+	present := true
+	if p.key != "" {
+		value, present = lookupValue(value, p.key)
+	}
+
+	return validateField(nil, p.key, p.typ, value, present, parseRules("")).Errors()
+}
+
+type fieldRules struct {
+	required bool
+	min, max *float64
+	enum     []string
+	format   string
+	pattern  *regexp.Regexp
+	err      error
+}
+
+func parseRules(tag string) fieldRules {
+	var rules fieldRules
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+
+		name, arg := strings.TrimSpace(rule), ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = strings.TrimSpace(rule[:i]), rule[i+1:]
+		}
+
+		switch name {
+		case "required":
+			rules.required = true
+		case "min", "max":
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				rules.err = fmt.Errorf("invalid rule %s", rule)
+				continue
+			}
+			if name == "min" {
+				rules.min = &f
+			} else {
+				rules.max = &f
+			}
+		case "enum":
+			rules.enum = strings.Split(arg, "|")
+		case "format":
+			rules.format = arg
+		case "pattern":
+			reg, err := regexp.Compile(arg)
+			if err != nil {
+				rules.err = fmt.Errorf("invalid rule %s", rule)
+				continue
+			}
+			rules.pattern = reg
+		case "":
+		default:
+			rules.err = fmt.Errorf("unknown rule %s", rule)
+		}
+	}
+	return rules
+}
+
+func validateField(errs errors.Errors, key string, typ reflect.Type, value interface{}, present bool,
+	rules fieldRules) errors.Errors {
+	if rules.err != nil {
+		return errs.Append(&ValidationError{Key: key, Message: rules.err.Error()})
+	}
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if !present || value == nil {
+		if rules.required {
+			errs = errs.Append(&ValidationError{Key: key, Message: "is required"})
+		}
+		return errs
+	}
+
+	if msg := checkType(typ, value); msg != "" {
+		return errs.Append(&ValidationError{Key: key, Message: msg})
+	}
+	if msg := checkRules(rules, value); msg != "" {
+		errs = errs.Append(&ValidationError{Key: key, Message: msg})
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ == timeType {
+			return errs
+		}
+		values, _ := toStringMap(value)
+		return validateStruct(errs, key, typ, values)
+	case reflect.Slice, reflect.Array:
+		vs := reflect.ValueOf(value)
+		for i := 0; i < vs.Len(); i++ {
+			errs = validateField(errs, indexKey(key, i), typ.Elem(), vs.Index(i).Interface(),
+				true, fieldRules{})
+		}
+	case reflect.Map:
+		values, _ := toStringMap(value)
+		for k, v := range values {
+			errs = validateField(errs, joinKey(key, k), typ.Elem(), v, true, fieldRules{})
+		}
+	}
+	return errs
+}
+
+func validateStruct(errs errors.Errors, key string, typ reflect.Type, values map[string]interface{}) errors.Errors {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, inline := fieldKey(field)
+		if name == "-" {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if inline && ft.Kind() == reflect.Struct {
+			errs = validateStruct(errs, key, ft, values)
+			continue
+		}
+
+		value, present := values[name]
+		errs = validateField(errs, joinKey(key, name), field.Type, value, present,
+			parseRules(field.Tag.Get(validateTag)))
+	}
+	return errs
+}
+
+// fieldKey return field's key by tag yaml, json, or lower case of the field name
+func fieldKey(field reflect.StructField) (string, bool) {
+	for _, tagName := range []string{"yaml", "json"} {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		inline := false
+		for _, o := range opts[1:] {
+			if o == "inline" {
+				inline = true
+			}
+		}
+		if opts[0] != "" {
+			return opts[0], inline
+		}
+		return strings.ToLower(field.Name), inline || field.Anonymous
+	}
+	return strings.ToLower(field.Name), field.Anonymous
+}
+
+func checkType(typ reflect.Type, value interface{}) string {
+	vt := reflect.TypeOf(value)
+	switch {
+	case typ == durationType:
+		if _, ok := toDuration(value); !ok {
+			return "invalid duration"
+		}
+		return ""
+	case typ == timeType:
+		if _, ok := value.(time.Time); ok {
+			return ""
+		}
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339, s); err == nil {
+				return ""
+			}
+		}
+		return "invalid time, expected RFC3339"
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		if _, ok := toBool(value); !ok {
+			return "invalid type, expected bool"
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return "invalid type, expected integer"
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := toFloat(value); !ok {
+			return "invalid type, expected number"
+		}
+	case reflect.String:
+		switch vt.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			return "invalid type, expected string"
+		}
+	case reflect.Struct, reflect.Map:
+		if _, ok := toStringMap(value); !ok {
+			return "invalid type, expected map"
+		}
+	case reflect.Slice, reflect.Array:
+		if vt.Kind() != reflect.Slice && vt.Kind() != reflect.Array {
+			return "invalid type, expected list"
+		}
+	}
+	return ""
+}
+
+func checkRules(rules fieldRules, value interface{}) string {
+	if rules.min != nil || rules.max != nil {
+		size, ok := valueSize(value)
+		if ok && rules.min != nil && size < *rules.min {
+			return fmt.Sprintf("must be >= %v", *rules.min)
+		}
+		if ok && rules.max != nil && size > *rules.max {
+			return fmt.Sprintf("must be <= %v", *rules.max)
+		}
+	}
+
+	if len(rules.enum) > 0 && !formats.StringInSlice(fmt.Sprint(value), rules.enum) {
+		return fmt.Sprintf("must be one of %v", rules.enum)
+	}
+
+	if rules.format != "" && !checkFormat(rules.format, fmt.Sprint(value)) {
+		return fmt.Sprintf("invalid format %s", rules.format)
+	}
+
+	if rules.pattern != nil && !rules.pattern.MatchString(fmt.Sprint(value)) {
+		return fmt.Sprintf("does not match pattern %s", rules.pattern.String())
+	}
+	return ""
+}
+
+func checkFormat(format, s string) bool {
+	switch format {
+	case "email":
+		return emailReg.MatchString(s)
+	case "url", "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "ip", "ipv4", "ipv6":
+		ip := net.ParseIP(s)
+		switch {
+		case ip == nil:
+			return false
+		case format == "ipv4":
+			return ip.To4() != nil
+		case format == "ipv6":
+			return ip.To4() == nil
+		}
+		return true
+	case "hostport":
+		_, _, err := net.SplitHostPort(s)
+		return err == nil
+	case "duration":
+		_, ok := toDuration(s)
+		return ok
+	case "bytesize":
+		return formats.ParseStringByteSize(strings.ToLower(s)) != nil
+	}
+	return false
+}
+
+// valueSize return number's value, or length of string, list and map
+func valueSize(value interface{}) (float64, bool) {
+	switch vt := reflect.ValueOf(value); vt.Kind() {
+	case reflect.String:
+		if _, ok := value.(json.Number); ok {
+			return toFloat(value)
+		}
+		return float64(utf8.RuneCountInString(vt.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(vt.Len()), true
+	}
+	return toFloat(value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflect.ValueOf(value).Uint()), true
+	case reflect.Bool, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		return 0, false
+	}
+	f, err := formats.ToFloat64(value)
+	return f, err == nil
+}
+
+func toBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "on":
+			return true, true
+		case "false", "off":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func toDuration(value interface{}) (time.Duration, bool) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, true
+		}
+		d := formats.ParseStringTime(strings.ToLower(s), -1)
+		return d, d != -1
+	}
+	f, ok := toFloat(value)
+	return time.Duration(f), ok && f == math.Trunc(f)
+}
+
+// configValues return the root values of configs
+func configValues(c Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, k := range c.GetKeys() {
+		// keys with special characters are quoted by GetKeys
+		values[unquoteKey(k)] = c.GetInterface(k)
+	}
+	return values
+}
+
+// lookupValue return the value of dot separated key in maps
+func lookupValue(value interface{}, key string) (interface{}, bool) {
+	tokens, err := parseKey(key)
+	if err != nil {
+		return nil, false
+	}
+	value, ok, err := lookupTokens(value, tokens)
+	return value, ok && err == nil
+}
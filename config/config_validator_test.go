@@ -0,0 +1,129 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/errors"
+	"github.com/iTrellis/common/testutils"
+)
+
+type validatorDB struct {
+	Host    string        `yaml:"host" validate:"required,format=hostport"`
+	Mode    string        `yaml:"mode" validate:"enum=debug|release"`
+	Port    int           `yaml:"port" validate:"min=1,max=65535"`
+	User    string        `yaml:"user" validate:"required,pattern=^[a-z]+$"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type validatorModel struct {
+	DB      validatorDB   `yaml:"db"`
+	Servers []validatorDB `yaml:"servers"`
+	Admin   string        `yaml:"admin" validate:"format=email"`
+}
+
+func TestStructValidator(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost:3306
+  mode: debug
+  port: 3306
+  user: root
+  timeout: 10s
+servers:
+  - host: localhost:80
+    user: admin
+admin: admin@example.com
+`),
+		config.OptionValidator(config.NewStructValidator(validatorModel{})))
+	testutils.Ok(t, err)
+
+	var db validatorDB
+	testutils.Ok(t, config.Bind(c, "db", &db))
+	testutils.Equals(t, 3306, db.Port)
+
+	_, err = config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost
+  mode: test
+  port: 0
+  timeout: x
+servers:
+  - host: localhost:80
+    user: Admin
+admin: admin
+`),
+		config.OptionValidator(config.NewStructValidator(validatorModel{})))
+	testutils.NotOk(t, err)
+
+	errs, ok := err.(errors.Errors)
+	testutils.Assert(t, ok, "error should be errors.Errors")
+
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.(*config.ValidationError).Key)
+	}
+	testutils.Equals(t, []string{"db.host", "db.mode", "db.port", "db.user", "db.timeout",
+		"servers[0].user", "admin"}, keys)
+}
+
+func TestSchemaValidator(t *testing.T) {
+	v, err := config.NewSchemaValidator([]byte(`{
+		"type": "object",
+		"required": ["db"],
+		"properties": {
+			"db": {
+				"type": "object",
+				"required": ["host", "user"],
+				"properties": {
+					"host": {"type": "string", "format": "hostport"},
+					"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+					"mode": {"enum": ["debug", "release"]}
+				}
+			},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "minLength": 2}}
+		}
+	}`))
+	testutils.Ok(t, err)
+
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeJSON, `{"db": {"host": "localhost:80", "user": "root", "port": 80}}`),
+		config.OptionValidator(v))
+	testutils.Ok(t, err)
+	testutils.Equals(t, 80, c.GetInt("db.port"))
+
+	_, err = config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeJSON,
+			`{"db": {"host": "localhost", "port": 0.5, "mode": "x"}, "tags": ["a", "bc", "de"]}`),
+		config.OptionValidator(v))
+	testutils.NotOk(t, err)
+
+	errs, ok := err.(errors.Errors)
+	testutils.Assert(t, ok, "error should be errors.Errors")
+
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.(*config.ValidationError).Key)
+	}
+	testutils.Equals(t, []string{"db.user", "db.host", "db.mode", "db.port", "tags", "tags[0]"}, keys)
+}
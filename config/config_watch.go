@@ -0,0 +1,206 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval default interval of checking the config file
+const DefaultWatchInterval = time.Second * 5
+
+// ChangeFunc is called with the changed keys after the configs were reloaded
+type ChangeFunc func(keys []string)
+
+// OptionWatch watch the config file and reload it when it was changed
+func OptionWatch(interval ...time.Duration) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.watchInterval = DefaultWatchInterval
+		if len(interval) > 0 && interval[0] > 0 {
+			c.watchInterval = interval[0]
+		}
+	}
+}
+
+// OptionOnChange register a function called after the configs were changed
+func OptionOnChange(fn ChangeFunc) OptionFunc {
+	return func(c *AdapterConfig) {
+		if fn != nil {
+			c.onChanges = append(c.onChanges, fn)
+		}
+	}
+}
+
+// OptionOnWatchError register a function called when watcher failed to reload the config file
+func OptionOnWatchError(fn func(error)) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.onWatchError = fn
+	}
+}
+
+// OnChange register a function called after the configs were changed
+func (p *AdapterConfig) OnChange(fn ChangeFunc) {
+	if fn == nil {
+		return
+	}
+	p.watchLocker.Lock()
+	p.onChanges = append(p.onChanges, fn)
+	p.watchLocker.Unlock()
+}
+
+// Reload read the config file again, and swap the configs if it is parsed ok
+func (p *AdapterConfig) Reload() error {
+	if len(p.ConfigFile) == 0 {
+		return ErrInvalidFilePath
+	}
+
+	// the file may be replaced by a new one, so do not use the opened one
+	if err := filesRepo.Close(p.ConfigFile); err != nil {
+		return err
+	}
+
+	data, _, err := filesRepo.Read(p.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	nc := p.clone(make(map[string]interface{}))
+	if err = nc.reader.ParseData(data, &nc.configs); err != nil {
+		return err
+	}
+	if err = nc.resolve(); err != nil {
+		return err
+	}
+
+	p.locker.Lock()
+	olds := p.configs
+	p.data, p.configs = data, nc.configs
+	p.record("reload")
+	p.locker.Unlock()
+
+	p.notifyChanges(changedKeys("", olds, nc.configs))
+	return nil
+}
+
+// Close stop watching the config file
+func (p *AdapterConfig) Close() error {
+	p.watchLocker.Lock()
+	defer p.watchLocker.Unlock()
+	if p.stopWatch != nil {
+		close(p.stopWatch)
+		p.stopWatch = nil
+	}
+	return nil
+}
+
+func (p *AdapterConfig) notifyChanges(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	p.watchLocker.Lock()
+	fns := make([]ChangeFunc, len(p.onChanges))
+	copy(fns, p.onChanges)
+	p.watchLocker.Unlock()
+
+	for _, fn := range fns {
+		fn(keys)
+	}
+}
+
+func (p *AdapterConfig) watch() error {
+	if p.watchInterval <= 0 {
+		return nil
+	}
+
+	if len(p.ConfigFile) == 0 {
+		return ErrInvalidFilePath
+	}
+
+	fi, err := os.Stat(p.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	p.watchLocker.Lock()
+	p.stopWatch = make(chan struct{})
+	stop := p.stopWatch
+	p.watchLocker.Unlock()
+
+	go func(modTime time.Time, size int64) {
+		ticker := time.NewTicker(p.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fi, err := os.Stat(p.ConfigFile)
+				if err != nil {
+					p.watchError(err)
+					continue
+				}
+				if fi.ModTime().Equal(modTime) && fi.Size() == size {
+					continue
+				}
+				modTime, size = fi.ModTime(), fi.Size()
+				if err = p.Reload(); err != nil {
+					p.watchError(err)
+				}
+			}
+		}
+	}(fi.ModTime(), fi.Size())
+
+	return nil
+}
+
+func (p *AdapterConfig) watchError(err error) {
+	if p.onWatchError != nil {
+		p.onWatchError(err)
+	}
+}
+
+// changedKeys return the sorted key paths whose values are different in olds and news
+func changedKeys(prefix string, olds, news map[string]interface{}) []string {
+	var keys []string
+	for _, c := range diffValues(prefix, olds, news) {
+		keys = append(keys, c.Key)
+	}
+	return keys
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch t := v.(type) {
+	case Options:
+		return t, true
+	case map[string]interface{}:
+		return t, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			sk, ok := k.(string)
+			if !ok {
+				continue
+			}
+			result[sk] = v
+		}
+		return result, true
+	}
+	return nil, false
+}
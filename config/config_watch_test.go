@@ -0,0 +1,63 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/config"
+	"github.com/iTrellis/common/testutils"
+)
+
+func TestWatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config_watch")
+	testutils.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "watch.yml")
+	err = ioutil.WriteFile(name, []byte("log:\n  level: info\nref: ${log.level}\nport: 80\n"), 0644)
+	testutils.Ok(t, err)
+
+	changes := make(chan []string, 1)
+	c, err := config.NewConfigOptions(
+		config.OptionFile(name),
+		config.OptionWatch(time.Millisecond*10),
+		config.OptionOnChange(func(keys []string) { changes <- keys }))
+	testutils.Ok(t, err)
+	defer c.(*config.AdapterConfig).Close()
+
+	testutils.Equals(t, "info", c.GetString("log.level"))
+
+	err = ioutil.WriteFile(name, []byte("log:\n  level: debug\nref: ${log.level}\nport: 80\nname: x\n"), 0644)
+	testutils.Ok(t, err)
+
+	select {
+	case keys := <-changes:
+		testutils.Equals(t, []string{"log.level", "name", "ref"}, keys)
+	case <-time.After(time.Second * 5):
+		t.Fatal("config was not reloaded")
+	}
+
+	testutils.Equals(t, "debug", c.GetString("log.level"))
+	testutils.Equals(t, "debug", c.GetString("ref"))
+	testutils.Equals(t, 80, c.GetInt("port"))
+}
@@ -28,4 +28,11 @@ var (
 	ErrInvalidFilePath        = errors.New("invalid file path")
 	ErrUnknownSuffixes        = errors.New("unknown file with suffix")
 	ErrNotSupportedReaderType = errors.New("not supported reader type")
+	ErrCircularReference      = errors.New("circular reference of keys")
+	ErrConflictingKeys        = errors.New("conflicting keys of a value and its children")
+	ErrSecretKeyNotFound      = errors.New("secret key not found")
+	ErrInvalidEncryptedValue  = errors.New("invalid encrypted value")
+	ErrKeyNotFound            = errors.New("key not found")
+	ErrIndexOutOfRange        = errors.New("index out of range")
+	ErrSnapshotNotFound       = errors.New("snapshot not found")
 )
@@ -4,6 +4,9 @@
 package config
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/iTrellis/common/files"
 )
 
@@ -21,6 +24,10 @@ const (
 	ReaderTypeYAML
 	// ReaderTypeXML xml reader type
 	ReaderTypeXML
+	// ReaderTypeTOML toml reader type
+	ReaderTypeTOML
+	// ReaderTypeINI ini reader type
+	ReaderTypeINI
 )
 
 // Reader reader repo
@@ -57,6 +64,10 @@ func NewReader(rt ReaderType, filename string) (Reader, error) {
 		return NewXMLReader(ReaderOptionFilename(filename)), nil
 	case ReaderTypeYAML:
 		return NewYAMLReader(ReaderOptionFilename(filename)), nil
+	case ReaderTypeTOML:
+		return NewTOMLReader(ReaderOptionFilename(filename)), nil
+	case ReaderTypeINI:
+		return NewINIReader(ReaderOptionFilename(filename)), nil
 	default:
 		return nil, ErrNotSupportedReaderType
 	}
@@ -94,3 +105,21 @@ func isWhitespace(c byte) bool {
 	}
 	return false
 }
+
+// inferScalar convert text of the formats without types (ini, xml) into bool, int64, float64 or string,
+// numbers are inferred only if they are formatted back into the same text, exp: 010 is a string
+func inferScalar(s string) interface{} {
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,254 @@
+/*
+Copyright © 2017 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iTrellis/common/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type defINIReader struct {
+	opts ReaderOptions
+}
+
+// NewINIReader return an ini reader
+//
+//	a = 1           => a: 1
+//	[b.c]
+//	d = "x"         => b.c.d: x
+//	e[] = 1         => b.c.e: [1, 2]
+//	e[] = 2
+func NewINIReader(opts ...ReaderOptionFunc) Reader {
+	r := &defINIReader{}
+	for _, o := range opts {
+		o(&r.opts)
+	}
+	return r
+}
+
+func (p *defINIReader) Read(model interface{}) error {
+	data, err := ReadINIFile(p.opts.filename)
+	if err != nil {
+		return err
+	}
+	return ParseINIConfig(data, model)
+}
+
+func (*defINIReader) Dump(v interface{}) ([]byte, error) {
+	return DumpINIConfig(v)
+}
+
+func (*defINIReader) ParseData(data []byte, model interface{}) error {
+	return ParseINIConfig(data, model)
+}
+
+// ReadINIFile 读取ini文件的配置信息
+func ReadINIFile(name string) ([]byte, error) {
+	data, _, err := filesRepo.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseINIConfig 解析ini的配置信息
+func ParseINIConfig(data []byte, model interface{}) error {
+	values := make(map[string]interface{})
+	section := values
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+
+		if text[0] == '[' {
+			if text[len(text)-1] != ']' {
+				return errors.Newf("invalid ini section at line %d: %s", line, text)
+			}
+			section = values
+			for _, name := range strings.Split(text[1:len(text)-1], ".") {
+				name = strings.TrimSpace(name)
+				sub, ok := section[name].(map[string]interface{})
+				if !ok {
+					sub = make(map[string]interface{})
+					section[name] = sub
+				}
+				section = sub
+			}
+			continue
+		}
+
+		i := strings.IndexAny(text, "=:")
+		if i <= 0 {
+			return errors.Newf("invalid ini key value at line %d: %s", line, text)
+		}
+		key, value := strings.TrimSpace(text[:i]), parseINIValue(strings.TrimSpace(text[i+1:]))
+		if strings.HasSuffix(key, "[]") {
+			key = strings.TrimSuffix(key, "[]")
+			list, _ := section[key].([]interface{})
+			section[key] = append(list, value)
+			continue
+		}
+		section[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if m, ok := model.(*map[string]interface{}); ok {
+		if *m == nil {
+			*m = values
+			return nil
+		}
+		for k, v := range values {
+			(*m)[k] = v
+		}
+		return nil
+	}
+
+	bs, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(bs, model)
+}
+
+func parseINIValue(s string) interface{} {
+	s = trimINIComment(s)
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '"' && s[len(s)-1] == '"':
+			if v, err := strconv.Unquote(s); err == nil {
+				return v
+			}
+			return s[1 : len(s)-1]
+		case s[0] == '\'' && s[len(s)-1] == '\'':
+			return s[1 : len(s)-1]
+		}
+	}
+
+	return inferScalar(s)
+}
+
+// trimINIComment remove the inline comment after " ;" or " #", which is out of the quoted value
+func trimINIComment(s string) string {
+	start := 0
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' && s[0] == '"' {
+				i++
+			} else if s[i] == s[0] {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	for _, sep := range []string{" ;", " #", "\t;", "\t#"} {
+		if i := strings.Index(s[start:], sep); i >= 0 {
+			s = s[:start+i]
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// DumpINIConfig 生成ini的配置信息
+func DumpINIConfig(v interface{}) ([]byte, error) {
+	values, ok := v.(map[string]interface{})
+	if !ok {
+		if opts, isOpts := v.(Options); isOpts {
+			values, ok = opts, true
+		}
+	}
+	if !ok {
+		bs, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(bs, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := dumpINISection(buf, "", values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func dumpINISection(buf *bytes.Buffer, name string, values map[string]interface{}) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sections []string
+	if name != "" {
+		fmt.Fprintf(buf, "[%s]\n", name)
+	}
+	for _, k := range keys {
+		if _, ok := toStringMap(values[k]); ok {
+			sections = append(sections, k)
+			continue
+		}
+
+		vs := reflect.ValueOf(values[k])
+		if values[k] != nil && vs.Kind() == reflect.Slice {
+			for i := 0; i < vs.Len(); i++ {
+				fmt.Fprintf(buf, "%s[] = %s\n", k, formatINIValue(vs.Index(i).Interface()))
+			}
+			continue
+		}
+		fmt.Fprintf(buf, "%s = %s\n", k, formatINIValue(values[k]))
+	}
+
+	for _, k := range sections {
+		sub, _ := toStringMap(values[k])
+		buf.WriteString("\n")
+		if err := dumpINISection(buf, strings.TrimPrefix(name+"."+k, "."), sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatINIValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return `""`
+	case string:
+		if _, ok := parseINIValue(t).(string); ok && t == strings.TrimSpace(t) &&
+			!strings.ContainsAny(t, ";#\"'") {
+			return t
+		}
+		return strconv.Quote(t)
+	}
+	return fmt.Sprint(v)
+}
@@ -27,7 +27,7 @@ type defSuffixReader struct {
 }
 
 // NewSuffixReader return a suffix reader
-// supportted: .json, .xml, .yaml, .yml
+// supportted: .json, .xml, .yaml, .yml, .toml, .ini
 func NewSuffixReader(opts ...ReaderOptionFunc) (reader Reader, err error) {
 	r := &defSuffixReader{}
 
@@ -67,6 +67,10 @@ func fileToReader(filename string) (Reader, error) {
 	case strings.HasSuffix(filename, ".yml"),
 		strings.HasSuffix(filename, ".yaml"):
 		return NewYAMLReader(ReaderOptionFilename(filename)), nil
+	case strings.HasSuffix(filename, ".toml"):
+		return NewTOMLReader(ReaderOptionFilename(filename)), nil
+	case strings.HasSuffix(filename, ".ini"):
+		return NewINIReader(ReaderOptionFilename(filename)), nil
 	default:
 		return nil, ErrUnknownSuffixes
 	}
@@ -81,6 +85,10 @@ func fileToReaderType(name string) ReaderType {
 	case strings.HasSuffix(name, ".yml"),
 		strings.HasSuffix(name, ".yaml"):
 		return ReaderTypeYAML
+	case strings.HasSuffix(name, ".toml"):
+		return ReaderTypeTOML
+	case strings.HasSuffix(name, ".ini"):
+		return ReaderTypeINI
 	default:
 		return ReaderTypeSuffix
 	}
@@ -18,7 +18,20 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package config
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// xmlRootName root element name of dumped configs
+	xmlRootName = "config"
+	// xmlTextKey key of element's text if the element has attributes or children
+	xmlTextKey = "#text"
 )
 
 type defXMLReader struct {
@@ -26,6 +39,14 @@ type defXMLReader struct {
 }
 
 // NewXMLReader return xml config reader
+// configs are parsed into maps without the root element:
+// attributes and children are keys of the element, repeated children are lists
+//
+//	<config>
+//	  <server host="localhost" port="80"/>      => server.host: localhost, server.port: 80
+//	  <tag>a</tag>
+//	  <tag>b</tag>                              => tag: [a, b]
+//	</config>
 func NewXMLReader(opts ...ReaderOptionFunc) Reader {
 	r := &defXMLReader{}
 	for _, o := range opts {
@@ -43,14 +64,14 @@ func (p *defXMLReader) Read(model interface{}) error {
 }
 
 func (*defXMLReader) Dump(v interface{}) ([]byte, error) {
-	return xml.Marshal(v)
+	return DumpXMLConfig(v)
 }
 
 func (*defXMLReader) ParseData(data []byte, model interface{}) error {
 	return ParseXMLConfig(data, model)
 }
 
-// ReadXMLFile 读取yaml文件的配置信息
+// ReadXMLFile 读取xml文件的配置信息
 func ReadXMLFile(name string) ([]byte, error) {
 	data, _, err := filesRepo.Read(name)
 	if err != nil {
@@ -59,7 +80,146 @@ func ReadXMLFile(name string) ([]byte, error) {
 	return data, nil
 }
 
-// ParseXMLConfig 解析yaml的配置信息
+// ParseXMLConfig 解析xml的配置信息
 func ParseXMLConfig(data []byte, model interface{}) error {
-	return xml.Unmarshal(data, model)
+	m, ok := model.(*map[string]interface{})
+	if !ok {
+		return xml.Unmarshal(data, model)
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		v, err := decodeXMLElement(d, start)
+		if err != nil {
+			return err
+		}
+		values, ok := v.(map[string]interface{})
+		if !ok {
+			return ErrNotMap
+		}
+		if *m == nil {
+			*m = values
+			return nil
+		}
+		for k, v := range values {
+			(*m)[k] = v
+		}
+		return nil
+	}
+}
+
+func decodeXMLElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	values := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		values[attr.Name.Local] = inferScalar(attr.Value)
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := decodeXMLElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch ov := values[name].(type) {
+			case nil:
+				values[name] = v
+			case []interface{}:
+				values[name] = append(ov, v)
+			default:
+				values[name] = []interface{}{ov, v}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(values) == 0 {
+				return inferScalar(s), nil
+			}
+			if s != "" {
+				values[xmlTextKey] = inferScalar(s)
+			}
+			return values, nil
+		}
+	}
+}
+
+// DumpXMLConfig 生成xml的配置信息, maps are dumped in the root element <config>
+func DumpXMLConfig(v interface{}) ([]byte, error) {
+	values, ok := toStringMap(v)
+	if !ok {
+		return xml.Marshal(v)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encodeXMLElement(buf, xmlRootName, values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(buf *bytes.Buffer, name string, v interface{}) error {
+	if v == nil {
+		fmt.Fprintf(buf, "<%s/>", name)
+		return nil
+	}
+
+	if values, ok := toStringMap(v); ok {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(buf, "<%s>", name)
+		for _, k := range keys {
+			if k == xmlTextKey {
+				if err := xml.EscapeText(buf, []byte(fmt.Sprint(values[k]))); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encodeXMLElement(buf, k, values[k]); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(buf, "</%s>", name)
+		return nil
+	}
+
+	if vs := reflect.ValueOf(v); vs.Kind() == reflect.Slice && vs.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < vs.Len(); i++ {
+			if err := encodeXMLElement(buf, name, vs.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	fmt.Fprintf(buf, "<%s>", name)
+	if err := xml.EscapeText(buf, []byte(fmt.Sprint(v))); err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "</%s>", name)
+	return nil
 }
@@ -19,6 +19,7 @@ package discovery
 
 import (
 	"context"
+	"sync"
 
 	"github.com/iTrellis/common/codec"
 	"github.com/iTrellis/common/discovery/etcd"
@@ -76,6 +77,33 @@ type Client interface {
 	WatchPrefix(ctx context.Context, prefix string, f func(string, interface{}) bool)
 }
 
+// ReadyWatcher is a client notifying when the watch is registered,
+// the changes after ready are all watched
+type ReadyWatcher interface {
+	// WatchPrefixReady calls ready once the watch is registered, then calls f
+	// whenever any value stored under prefix changes.
+	WatchPrefixReady(ctx context.Context, prefix string, ready func(), f func(string, interface{}) bool)
+}
+
+// StartWatchPrefix watches the prefix in background, it returns after the watch is registered
+// if the client is a ReadyWatcher, or returns at once
+func StartWatchPrefix(ctx context.Context, client Client, prefix string, f func(string, interface{}) bool) {
+	rw, ok := client.(ReadyWatcher)
+	if !ok {
+		go client.WatchPrefix(ctx, prefix, f)
+		return
+	}
+
+	ready := make(chan struct{})
+	var once sync.Once
+	go func() {
+		rw.WatchPrefixReady(ctx, prefix, func() { once.Do(func() { close(ready) }) }, f)
+		// the watch may be stopped before it is ready
+		once.Do(func() { close(ready) })
+	}()
+	<-ready
+}
+
 // , reg prometheus.Registerer
 func createClient(backend string, prefix string, cfg Config, codec codec.Codec) (Client, error) {
 	var client Client
@@ -88,6 +116,9 @@ func createClient(backend string, prefix string, cfg Config, codec codec.Codec)
 	case "etcd":
 		client, err = etcd.New(cfg.Etcd, codec)
 
+	case "inmemory":
+		client = NewInMemoryClient(codec)
+
 	// This case is for testing. The mock KV client does not do anything internally.
 	case "mock":
 		client, err = buildMockClient()
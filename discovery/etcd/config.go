@@ -347,6 +347,11 @@ outer:
 
 // WatchPrefix implements kv.Client.
 func (c *Client) WatchPrefix(ctx context.Context, key string, f func(string, interface{}) bool) {
+	c.WatchPrefixReady(ctx, key, nil, f)
+}
+
+// WatchPrefixReady implements discovery.ReadyWatcher, ready is called when the first watch is created.
+func (c *Client) WatchPrefixReady(ctx context.Context, key string, ready func(), f func(string, interface{}) bool) {
 	backoff := backoff.New(ctx, backoff.Config{
 		MinBackoff: 1 * time.Second,
 		MaxBackoff: 1 * time.Minute,
@@ -358,7 +363,7 @@ func (c *Client) WatchPrefix(ctx context.Context, key string, f func(string, int
 
 outer:
 	for backoff.Ongoing() {
-		for resp := range c.cli.Watch(watchCtx, key, clientv3.WithPrefix()) {
+		for resp := range c.cli.Watch(watchCtx, key, clientv3.WithPrefix(), clientv3.WithCreatedNotify()) {
 			if err := resp.Err(); err != nil {
 				// level.Error(util_log.Logger).Log("msg", "watch error", "key", key, "err", err)
 				continue outer
@@ -366,6 +371,11 @@ outer:
 
 			backoff.Reset()
 
+			if resp.Created && ready != nil {
+				ready()
+				ready = nil
+			}
+
 			for _, event := range resp.Events {
 				if event.Kv.Version == 0 && event.Kv.Value == nil {
 					// Delete notification. Since not all KV store clients (and Cortex codecs) support this, we ignore it.
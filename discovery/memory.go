@@ -0,0 +1,253 @@
+/*
+Copyright © 2021 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iTrellis/common/codec"
+)
+
+// memoryClient is an in-memory Client, values are serialised by the codec.
+// It is used for testing and single process, watchers get nil values of the deleted keys.
+type memoryClient struct {
+	codec codec.Codec
+
+	locker   sync.RWMutex
+	values   map[string][]byte
+	watchers map[*memoryWatcher]struct{}
+}
+
+// memoryWatcher queues the events without blocking the writers,
+// so the callback of watcher can write the client
+type memoryWatcher struct {
+	prefix string
+
+	locker sync.Mutex
+	events []memoryEvent
+	signal chan struct{}
+}
+
+func (w *memoryWatcher) push(evt memoryEvent) {
+	w.locker.Lock()
+	w.events = append(w.events, evt)
+	w.locker.Unlock()
+
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *memoryWatcher) pop() []memoryEvent {
+	w.locker.Lock()
+	events := w.events
+	w.events = nil
+	w.locker.Unlock()
+	return events
+}
+
+type memoryEvent struct {
+	key     string
+	value   []byte
+	deleted bool
+}
+
+// NewInMemoryClient returns a Client keeping values in memory
+func NewInMemoryClient(codec codec.Codec) Client {
+	return &memoryClient{
+		codec:    codec,
+		values:   make(map[string][]byte),
+		watchers: make(map[*memoryWatcher]struct{}),
+	}
+}
+
+// List implements Client.
+func (m *memoryClient) List(ctx context.Context, prefix string) ([]string, error) {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	keys := []string{}
+	for k := range m.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Get implements Client.
+func (m *memoryClient) Get(ctx context.Context, key string) (interface{}, error) {
+	m.locker.RLock()
+	bs, ok := m.values[key]
+	m.locker.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return m.codec.Unmarshal(bs)
+}
+
+// Delete implements Client.
+func (m *memoryClient) Delete(ctx context.Context, key string) error {
+	m.locker.Lock()
+	if _, ok := m.values[key]; !ok {
+		m.locker.Unlock()
+		return nil
+	}
+	delete(m.values, key)
+	watchers := m.matchedWatchers(key)
+	m.locker.Unlock()
+
+	notifyWatchers(watchers, memoryEvent{key: key, deleted: true})
+	return nil
+}
+
+// CAS implements Client.
+func (m *memoryClient) CAS(ctx context.Context, key string,
+	f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.locker.RLock()
+		origin, exists := m.values[key]
+		m.locker.RUnlock()
+
+		var in interface{}
+		if exists {
+			var err error
+			if in, err = m.codec.Unmarshal(origin); err != nil {
+				return err
+			}
+		}
+
+		out, retry, err := f(in)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+
+		bs, err := m.codec.Marshal(out)
+		if err != nil {
+			return err
+		}
+
+		m.locker.Lock()
+		current, ok := m.values[key]
+		if ok != exists || !bytes.Equal(current, origin) {
+			m.locker.Unlock()
+			if retry {
+				continue
+			}
+			return nil
+		}
+		m.values[key] = bs
+		watchers := m.matchedWatchers(key)
+		m.locker.Unlock()
+
+		notifyWatchers(watchers, memoryEvent{key: key, value: bs})
+		return nil
+	}
+}
+
+// WatchKey implements Client.
+func (m *memoryClient) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	m.WatchPrefix(ctx, key, func(k string, v interface{}) bool {
+		if k != key {
+			return true
+		}
+		return f(v)
+	})
+}
+
+// WatchPrefix implements Client.
+func (m *memoryClient) WatchPrefix(ctx context.Context, prefix string, f func(string, interface{}) bool) {
+	m.WatchPrefixReady(ctx, prefix, nil, f)
+}
+
+// WatchPrefixReady implements ReadyWatcher.
+func (m *memoryClient) WatchPrefixReady(ctx context.Context, prefix string, ready func(),
+	f func(string, interface{}) bool) {
+	w := &memoryWatcher{
+		prefix: prefix,
+		signal: make(chan struct{}, 1),
+	}
+
+	m.locker.Lock()
+	m.watchers[w] = struct{}{}
+	m.locker.Unlock()
+
+	defer func() {
+		m.locker.Lock()
+		delete(m.watchers, w)
+		m.locker.Unlock()
+	}()
+
+	if ready != nil {
+		ready()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.signal:
+			for _, evt := range w.pop() {
+				if !m.callWatcher(evt, f) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// callWatcher calls f with the event, the deleted key is called with nil value
+func (m *memoryClient) callWatcher(evt memoryEvent, f func(string, interface{}) bool) bool {
+	if evt.deleted {
+		return f(evt.key, nil)
+	}
+	out, err := m.codec.Unmarshal(evt.value)
+	if err != nil {
+		return true
+	}
+	return f(evt.key, out)
+}
+
+func (m *memoryClient) matchedWatchers(key string) []*memoryWatcher {
+	var watchers []*memoryWatcher
+	for w := range m.watchers {
+		if strings.HasPrefix(key, w.prefix) {
+			watchers = append(watchers, w)
+		}
+	}
+	return watchers
+}
+
+func notifyWatchers(watchers []*memoryWatcher, evt memoryEvent) {
+	for _, w := range watchers {
+		w.push(evt)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package discovery_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/codec"
+	"github.com/iTrellis/common/discovery"
+	"github.com/iTrellis/common/testutils"
+)
+
+type watchEvent struct {
+	key   string
+	value interface{}
+}
+
+func TestInMemoryWatchPrefix(t *testing.T) {
+	client := discovery.NewInMemoryClient(codec.String{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan watchEvent, 8)
+	discovery.StartWatchPrefix(ctx, client, "/services/", func(key string, value interface{}) bool {
+		events <- watchEvent{key: key, value: value}
+		return true
+	})
+
+	next := func() watchEvent {
+		select {
+		case evt := <-events:
+			return evt
+		case <-time.After(time.Second * 5):
+			t.Fatal("event was not watched")
+		}
+		return watchEvent{}
+	}
+
+	put(t, client, "/services/a", "10.0.0.1")
+	testutils.Equals(t, watchEvent{key: "/services/a", value: "10.0.0.1"}, next())
+
+	testutils.Ok(t, client.Delete(ctx, "/services/a"))
+	testutils.Equals(t, watchEvent{key: "/services/a"}, next())
+
+	// deleting a missing key is not watched
+	testutils.Ok(t, client.Delete(ctx, "/services/a"))
+	put(t, client, "/services/b", "10.0.0.2")
+	testutils.Equals(t, watchEvent{key: "/services/b", value: "10.0.0.2"}, next())
+}
+
+func TestInMemoryWatcherWrites(t *testing.T) {
+	client := discovery.NewInMemoryClient(codec.String{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the watcher writes the client in its callback, writers are not blocked by it
+	done := make(chan struct{})
+	discovery.StartWatchPrefix(ctx, client, "/counter", func(key string, value interface{}) bool {
+		if value == "100" {
+			close(done)
+			return false
+		}
+		put(t, client, "/counter/echo", "x")
+		return true
+	})
+
+	for i := 0; i <= 100; i++ {
+		put(t, client, "/counter/value", fmt.Sprint(i))
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("watcher was blocked")
+	}
+}
+
+func put(t *testing.T, client discovery.Client, key, value string) {
+	err := client.CAS(context.Background(), key, func(interface{}) (interface{}, bool, error) {
+		return value, true, nil
+	})
+	testutils.Ok(t, err)
+}
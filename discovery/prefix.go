@@ -70,6 +70,22 @@ func (c *prefixedKVClient) WatchPrefix(ctx context.Context, prefix string, f fun
 	})
 }
 
+// WatchPrefixReady implements ReadyWatcher, ready is called at once if the client is not a ReadyWatcher.
+func (c *prefixedKVClient) WatchPrefixReady(ctx context.Context, prefix string, ready func(),
+	f func(string, interface{}) bool) {
+	fn := func(k string, i interface{}) bool {
+		return f(strings.TrimPrefix(k, c.prefix), i)
+	}
+	if rw, ok := c.client.(ReadyWatcher); ok {
+		rw.WatchPrefixReady(ctx, c.prefix+prefix, ready, fn)
+		return
+	}
+	if ready != nil {
+		ready()
+	}
+	c.client.WatchPrefix(ctx, c.prefix+prefix, fn)
+}
+
 // Get looks up a given object from its key.
 func (c *prefixedKVClient) Get(ctx context.Context, key string) (interface{}, error) {
 	return c.client.Get(ctx, c.prefix+key)
@@ -38,6 +38,14 @@ func NewDefSubscriber(sub interface{}) (Subscriber, error) {
 			id: GenSubscriberID(),
 			fn: s,
 		}
+	case func(...interface{}):
+		subscriber = &defSubscriber{
+			id: GenSubscriberID(),
+			fn: func(values ...interface{}) error {
+				s(values...)
+				return nil
+			},
+		}
 	case Subscriber:
 		subscriber = s
 	default:
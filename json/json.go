@@ -1,11 +1,15 @@
 package json
 
 import (
+	"encoding/json"
 	"io"
 
 	jsoniter "github.com/json-iterator/go"
 )
 
+// Number is the number literal produced by decoders with UseNumber
+type Number = json.Number
+
 func Marshal(v interface{}) ([]byte, error) {
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 	return json.Marshal(v)
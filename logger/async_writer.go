@@ -0,0 +1,207 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OverflowPolicy decides what the async writer does when its buffer is full
+type OverflowPolicy int
+
+// OverflowPolicies
+const (
+	// the writer waits for the space of the buffer
+	OverflowBlock OverflowPolicy = iota
+	// the written line is dropped
+	OverflowDropNewest
+	// the oldest line in the buffer is dropped
+	OverflowDropOldest
+)
+
+// DefaultAsyncBufferSize default number of lines in the buffer of the async writer
+const DefaultAsyncBufferSize = 8192
+
+// ErrAsyncWriterClosed the async writer is closed
+var ErrAsyncWriterClosed = errors.New("async writer closed")
+
+var _ zapcore.WriteSyncer = (*AsyncWriter)(nil)
+
+// AsyncWriter writes lines into the wrapped writer in background,
+// lines are kept in a bounded ring buffer until they are written.
+// The mpsc queue is not used, because the ring buffer is bounded and the oldest line is dropped by writers.
+type AsyncWriter struct {
+	ws     zapcore.WriteSyncer
+	policy OverflowPolicy
+
+	locker   sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	// broadcast when lines are written or dropped
+	progress *sync.Cond
+
+	lines [][]byte
+	head  int
+	size  int
+	// numbers of accepted lines, and lines which are written or dropped
+	pushed uint64
+	done   uint64
+	closed bool
+	err    error
+
+	dropped uint64
+	stopped chan struct{}
+}
+
+// NewAsyncWriter returns the writer which writes lines into ws in background,
+// size is the number of lines in the buffer, default: DefaultAsyncBufferSize
+func NewAsyncWriter(ws zapcore.WriteSyncer, size int, policy OverflowPolicy) *AsyncWriter {
+	if size <= 0 {
+		size = DefaultAsyncBufferSize
+	}
+	p := &AsyncWriter{
+		ws:      ws,
+		policy:  policy,
+		lines:   make([][]byte, size),
+		stopped: make(chan struct{}),
+	}
+	p.notEmpty = sync.NewCond(&p.locker)
+	p.notFull = sync.NewCond(&p.locker)
+	p.progress = sync.NewCond(&p.locker)
+
+	go p.flush()
+	return p
+}
+
+// Write copies the line into the buffer, the full buffer is handled by the overflow policy
+func (p *AsyncWriter) Write(bs []byte) (int, error) {
+	line := make([]byte, len(bs))
+	copy(line, bs)
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for !p.closed && p.size == len(p.lines) {
+		switch p.policy {
+		case OverflowDropNewest:
+			atomic.AddUint64(&p.dropped, 1)
+			return len(bs), nil
+		case OverflowDropOldest:
+			p.lines[p.head] = nil
+			p.head = (p.head + 1) % len(p.lines)
+			p.size--
+			p.done++
+			atomic.AddUint64(&p.dropped, 1)
+			p.progress.Broadcast()
+		default:
+			p.notFull.Wait()
+		}
+	}
+	if p.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+
+	p.lines[(p.head+p.size)%len(p.lines)] = line
+	p.size++
+	p.pushed++
+	p.notEmpty.Signal()
+	return len(bs), nil
+}
+
+// Sync waits for the lines written before, then syncs the wrapped writer;
+// it returns the last error of writing lines
+func (p *AsyncWriter) Sync() error {
+	p.locker.Lock()
+	for target := p.pushed; p.done < target; {
+		p.progress.Wait()
+	}
+	err := p.err
+	p.err = nil
+	p.locker.Unlock()
+
+	if serr := p.ws.Sync(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Close writes the lines in the buffer and stops the background flusher
+func (p *AsyncWriter) Close() error {
+	p.locker.Lock()
+	if p.closed {
+		p.locker.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.notEmpty.Broadcast()
+	p.notFull.Broadcast()
+	p.locker.Unlock()
+
+	<-p.stopped
+	return p.Sync()
+}
+
+// Dropped returns the number of dropped lines
+func (p *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+// flush writes the lines in the buffer until the writer is closed
+func (p *AsyncWriter) flush() {
+	defer close(p.stopped)
+
+	var batch [][]byte
+	for {
+		p.locker.Lock()
+		for !p.closed && p.size == 0 {
+			p.notEmpty.Wait()
+		}
+		if p.size == 0 {
+			p.locker.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for ; p.size > 0; p.size-- {
+			batch = append(batch, p.lines[p.head])
+			p.lines[p.head] = nil
+			p.head = (p.head + 1) % len(p.lines)
+		}
+		p.notFull.Broadcast()
+		p.locker.Unlock()
+
+		var err error
+		for _, line := range batch {
+			if _, werr := p.ws.Write(line); werr != nil {
+				err = werr
+			}
+		}
+
+		p.locker.Lock()
+		p.done += uint64(len(batch))
+		if err != nil {
+			p.err = err
+		}
+		p.progress.Broadcast()
+		p.locker.Unlock()
+	}
+}
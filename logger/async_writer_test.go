@@ -0,0 +1,156 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/logger"
+	"github.com/iTrellis/common/testutils"
+	"go.uber.org/zap/zapcore"
+)
+
+// gatedWriter blocks writes until it is opened
+type gatedWriter struct {
+	entered chan struct{}
+	gate    chan struct{}
+
+	locker sync.Mutex
+	lines  []string
+	syncs  int
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{entered: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (p *gatedWriter) Write(bs []byte) (int, error) {
+	p.entered <- struct{}{}
+	<-p.gate
+	p.locker.Lock()
+	p.lines = append(p.lines, string(bs))
+	p.locker.Unlock()
+	return len(bs), nil
+}
+
+func (p *gatedWriter) Sync() error {
+	p.locker.Lock()
+	p.syncs++
+	p.locker.Unlock()
+	return nil
+}
+
+func (p *gatedWriter) written() string {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	return strings.Join(p.lines, "")
+}
+
+// fill writes a which is being written by the flusher, then fills the buffer of size 2 with b and c
+func fill(t *testing.T, w *logger.AsyncWriter, gw *gatedWriter) {
+	_, err := w.Write([]byte("a"))
+	testutils.Ok(t, err)
+	<-gw.entered
+	for _, line := range []string{"b", "c"} {
+		_, err = w.Write([]byte(line))
+		testutils.Ok(t, err)
+	}
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	for policy, expected := range map[logger.OverflowPolicy]string{
+		logger.OverflowDropNewest: "abc",
+		logger.OverflowDropOldest: "acd",
+	} {
+		gw := newGatedWriter()
+		w := logger.NewAsyncWriter(gw, 2, policy)
+		fill(t, w, gw)
+
+		n, err := w.Write([]byte("d"))
+		testutils.Ok(t, err)
+		testutils.Equals(t, 1, n)
+		testutils.Equals(t, uint64(1), w.Dropped())
+
+		close(gw.gate)
+		testutils.Ok(t, w.Sync())
+		testutils.Equals(t, expected, gw.written())
+		testutils.Equals(t, 1, gw.syncs)
+		testutils.Ok(t, w.Close())
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	gw := newGatedWriter()
+	w := logger.NewAsyncWriter(gw, 2, logger.OverflowBlock)
+	fill(t, w, gw)
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("d"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("the write should be blocked by the full buffer")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	close(gw.gate)
+	<-written
+	testutils.Ok(t, w.Close())
+	testutils.Equals(t, "abcd", gw.written())
+	testutils.Equals(t, uint64(0), w.Dropped())
+
+	_, err := w.Write([]byte("e"))
+	testutils.ErrorEqual(t, logger.ErrAsyncWriterClosed, err)
+}
+
+func TestAsyncFileLogger(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	l, err := logger.NewLogger(
+		logger.LogLevel(logger.InfoLevel),
+		logger.EncoderConfig(&zapcore.EncoderConfig{MessageKey: "msg"}),
+		logger.LogFileOption(logger.OptionFilename(name), logger.OptionAsync(16, logger.OverflowBlock)),
+	)
+	testutils.Ok(t, err)
+
+	for i := 0; i < 100; i++ {
+		l.Info("line")
+	}
+	testutils.Ok(t, l.Sync())
+
+	bs, err := os.ReadFile(name)
+	testutils.Ok(t, err)
+	testutils.Equals(t, strings.Repeat("line\n", 100), string(bs))
+
+	// the buffered lines are written when the logger is closed
+	for i := 0; i < 50; i++ {
+		l.Named("module").Info("line")
+	}
+	testutils.Ok(t, l.Close())
+	testutils.Ok(t, l.Close())
+
+	bs, err = os.ReadFile(name)
+	testutils.Ok(t, err)
+	testutils.Equals(t, strings.Repeat("line\n", 150), string(bs))
+}
@@ -18,8 +18,9 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"compress/gzip"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/iTrellis/common/errors"
 	"github.com/iTrellis/common/files"
+	"github.com/klauspost/compress/zstd"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -41,6 +43,14 @@ type fileLogger struct {
 
 	mutex    sync.Mutex
 	fileRepo files.FileRepo
+
+	// size and move flag of the current file, the file is only stated when it is opened
+	size int64
+	flag int
+
+	// compression and removing of old files in background
+	millMutex sync.Mutex
+	millGroup sync.WaitGroup
 }
 
 // NewFileLogger 标准窗体的输出对象
@@ -72,47 +82,67 @@ func NewFileLoggerWithOptions(opts FileOptions) (*fileLogger, error) {
 }
 
 func (p *fileLogger) init() error {
-	if p == nil || p.options.Filename == "" {
+	if p == nil {
 		return errors.New("file name not exist")
 	}
+	if err := p.options.Check(); err != nil {
+		return err
+	}
 
 	p.fileRepo = files.NewFileRepo(files.ConcurrencyRead())
 	if p.options.Separator == "" {
 		p.options.Separator = "\t"
 	}
+	if p.options.BackupPattern == "" {
+		p.options.BackupPattern = DefaultBackupPattern
+	}
+	if p.options.BackupTimeLayout == "" {
+		p.options.BackupTimeLayout = DefaultBackupTimeLayout
+	}
 
-	if err := p.checkFile(); err != nil {
+	t := time.Now()
+	p.flag = p.options.MoveFileType.getMoveFileFlag(t)
+	fi, err := p.fileRepo.FileInfo(p.options.Filename)
+	switch {
+	case err == nil:
+		p.size = fi.Size()
+		if p.options.MoveFileType.getMoveFileFlag(fi.ModTime()) != p.flag {
+			return p.moveFile(t)
+		}
+	case !os.IsNotExist(err):
 		return err
 	}
 
-	return nil
+	return p.symlink()
 }
 
 func (p *fileLogger) Write(bs []byte) (int, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
-	if err := p.checkFile(); err != nil {
+	if err := p.checkFile(len(bs)); err != nil {
 		return 0, err
 	}
-	return p.fileRepo.WriteAppendBytes(p.options.Filename, bs)
+	n, err := p.fileRepo.WriteAppendBytes(p.options.Filename, bs)
+	p.size += int64(n)
+	return n, err
 }
 
 func (p *fileLogger) Sync() error { return nil }
 
-func (p *fileLogger) checkFile() (err error) {
-
-	fi, err := p.fileRepo.FileInfo(p.options.Filename)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return err
-		}
-		return nil
-	}
+// Close waits for the compression and removing of old files
+func (p *fileLogger) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.millGroup.Wait()
+	return p.fileRepo.CloseAll()
+}
 
+// checkFile moves the file if the time crosses the move file type or the file is full after writing n bytes
+func (p *fileLogger) checkFile(n int) error {
 	t := time.Now()
 
-	if p.options.MoveFileType.getMoveFileFlag(t) == p.options.MoveFileType.getMoveFileFlag(fi.ModTime()) &&
-		(p.options.MaxLength == 0 || (p.options.MaxLength > 0 && fi.Size() < p.options.MaxLength)) {
+	if p.options.MoveFileType.getMoveFileFlag(t) == p.flag &&
+		(p.options.MaxLength <= 0 || p.size == 0 || p.size+int64(n) <= p.options.MaxLength) {
 		return nil
 	}
 
@@ -120,56 +150,146 @@ func (p *fileLogger) checkFile() (err error) {
 }
 
 func (p *fileLogger) moveFile(t time.Time) error {
-
-	err := p.fileRepo.Rename(
-		p.options.Filename, fmt.Sprintf("%s_%s", p.options.Filename, t.Format("20060102150405.999999999")))
-	if err != nil {
+	backup := p.backupName(t)
+	err := p.fileRepo.Rename(p.options.Filename, backup)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
-	if err = p.removeOldFiles(); err != nil {
+	p.size, p.flag = 0, p.options.MoveFileType.getMoveFileFlag(t)
+	if _, err = p.fileRepo.Write(p.options.Filename, ""); err != nil {
 		return err
 	}
+	if err = p.symlink(); err != nil {
+		return err
+	}
+
+	p.millGroup.Add(1)
+	go func() {
+		defer p.millGroup.Done()
+		p.mill(backup)
+	}()
+	return nil
+}
+
+// backupName returns the unused name of the moved file by the backup pattern
+func (p *fileLogger) backupName(t time.Time) string {
+	ext := filepath.Ext(p.basename())
+	name := strings.NewReplacer(
+		"{filename}", p.basename(),
+		"{name}", strings.TrimSuffix(p.basename(), ext),
+		"{ext}", ext,
+		"{time}", t.Format(p.options.BackupTimeLayout),
+	).Replace(p.options.BackupPattern)
+
+	backup := filepath.Join(p.dir(), name)
+	for i := 1; p.backupExists(backup); i++ {
+		backup = filepath.Join(p.dir(), fmt.Sprintf("%s.%d", name, i))
+	}
+	return backup
+}
+
+func (p *fileLogger) backupExists(backup string) bool {
+	for _, name := range []string{backup, backup + compressExt(p.options.Compress)} {
+		if _, err := os.Stat(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
 
-	_, err = p.fileRepo.Write(p.options.Filename, "")
+// mill compresses the moved file and removes old files, it is called in background
+func (p *fileLogger) mill(backup string) {
+	p.millMutex.Lock()
+	defer p.millMutex.Unlock()
 
-	return err
+	if p.options.Compress != CompressNone {
+		// the uncompressed file is kept if it is failed to compress
+		_ = compressFile(backup, p.options.Compress)
+	}
+	_ = p.removeOldFiles()
 }
 
 func (p *fileLogger) removeOldFiles() error {
-	if p.options.MaxBackups == 0 {
+	if p.options.MaxBackups <= 0 && p.options.MaxAge <= 0 {
 		return nil
 	}
 
-	// 获取日志文件列表
-	dirLis, err := ioutil.ReadDir(p.dir())
+	backups, err := p.backups()
 	if err != nil {
 		return err
 	}
 
-	// 根据文件名过滤日志文件
-	fileSort := FileSort{}
-	filePrefix := fmt.Sprintf("%s_", p.basename())
-	for _, f := range dirLis {
-		if strings.Contains(f.Name(), filePrefix) {
-			fileSort = append(fileSort, f)
+	// 根据文件修改日期排序，保留最近的N个文件; 修改日期相同的文件按文件名排序
+	sort.Sort(FileSort(backups))
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].ModTime().After(backups[j].ModTime())
+	})
+
+	var errs errors.Errors
+	for i, f := range backups {
+		if (p.options.MaxBackups <= 0 || i < p.options.MaxBackups) &&
+			(p.options.MaxAge <= 0 || time.Since(f.ModTime()) <= p.options.MaxAge) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(p.dir(), f.Name())); err != nil {
+			errs = append(errs, err)
 		}
 	}
+	return errs.Errors()
+}
 
-	if len(fileSort) <= int(p.options.MaxBackups) {
-		return nil
+// backups returns the moved files matched by the backup pattern
+func (p *fileLogger) backups() ([]os.FileInfo, error) {
+	ext := filepath.Ext(p.basename())
+	pattern := strings.NewReplacer(
+		"{filename}", escapeGlob(p.basename()),
+		"{name}", escapeGlob(strings.TrimSuffix(p.basename(), ext)),
+		"{ext}", escapeGlob(ext),
+		"{time}", "*",
+	).Replace(escapeGlob(p.options.BackupPattern))
+
+	// the suffixes of the compressed files and the repeated names
+	matches, err := filepath.Glob(filepath.Join(p.dir(), pattern+"*"))
+	if err != nil {
+		return nil, err
 	}
 
-	// 根据文件修改日期排序，保留最近的N个文件
-	sort.Sort(fileSort)
-	for _, f := range fileSort[p.options.MaxBackups:] {
-		err := os.Remove(filepath.Join(p.dir(), f.Name()))
-		if err != nil {
-			return err
+	var backups []os.FileInfo
+	for _, m := range matches {
+		if m == p.options.Filename || m == p.options.Symlink {
+			continue
+		}
+		fi, err := os.Lstat(m)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
 		}
+		backups = append(backups, fi)
 	}
+	return backups, nil
+}
 
-	return nil
+// symlink links the symlink to the current file
+func (p *fileLogger) symlink() error {
+	if p.options.Symlink == "" {
+		return nil
+	}
+
+	target, err := filepath.Abs(p.options.Filename)
+	if err != nil {
+		return err
+	}
+	if current, err := os.Readlink(p.options.Symlink); err == nil && current == target {
+		return nil
+	}
+
+	// the new link replaces the old one atomically
+	tmp := p.options.Symlink + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.options.Symlink)
 }
 
 // dir returns the directory for the current filename.
@@ -181,3 +301,72 @@ func (p *fileLogger) dir() string {
 func (p *fileLogger) basename() string {
 	return filepath.Base(p.options.Filename)
 }
+
+func compressExt(compress string) string {
+	switch compress {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// compressFile compresses the file into the file with the compression suffix, and removes it
+func compressFile(name, compress string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstName := name + compressExt(compress)
+	dst, err := os.OpenFile(dstName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dstName)
+		}
+	}()
+
+	var w io.WriteCloser
+	switch compress {
+	case CompressGzip:
+		w = gzip.NewWriter(dst)
+	case CompressZstd:
+		if w, err = zstd.NewWriter(dst); err != nil {
+			dst.Close()
+			return err
+		}
+	}
+
+	if _, err = io.Copy(w, src); err != nil {
+		w.Close()
+		dst.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	// the compressed file keeps the modification time for the max age
+	if err = os.Chtimes(dstName, fi.ModTime(), fi.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`).Replace(s)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iTrellis/common/logger"
+	"github.com/iTrellis/common/testutils"
+	"github.com/klauspost/compress/zstd"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	testutils.Ok(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readCompressed(t *testing.T, name string) string {
+	f, err := os.Open(name)
+	testutils.Ok(t, err)
+	defer f.Close()
+
+	var r io.Reader
+	switch filepath.Ext(name) {
+	case ".gz":
+		gr, err := gzip.NewReader(f)
+		testutils.Ok(t, err)
+		r = gr
+	case ".zst":
+		zr, err := zstd.NewReader(f)
+		testutils.Ok(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		r = f
+	}
+	bs, err := io.ReadAll(r)
+	testutils.Ok(t, err)
+	return string(bs)
+}
+
+func TestFileLoggerRotation(t *testing.T) {
+	for _, compress := range []string{logger.CompressNone, logger.CompressGzip, logger.CompressZstd} {
+		dir := t.TempDir()
+		w, err := logger.NewFileLogger(
+			logger.OptionFilename(filepath.Join(dir, "app.log")),
+			logger.OptionMaxLength(10),
+			logger.OptionMaxBackups(2),
+			logger.OptionCompress(compress),
+			logger.OptionBackupPattern("{name}-{time}{ext}", "150405.000000000"),
+			logger.OptionSymlink(filepath.Join(dir, "current.log")),
+		)
+		testutils.Ok(t, err)
+
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+			_, err = w.Write([]byte(line))
+			testutils.Ok(t, err)
+		}
+		testutils.Ok(t, w.Close())
+
+		// the backups are sorted before app.log, the first backup is removed by max backups
+		names := listDir(t, dir)
+		testutils.Equals(t, 4, len(names))
+		testutils.Equals(t, []string{"app.log", "current.log"}, names[2:])
+
+		var contents []string
+		for _, name := range names[:2] {
+			testutils.Assert(t, strings.HasPrefix(name, "app-") && strings.HasSuffix(name, ".log"+map[string]string{
+				logger.CompressGzip: ".gz", logger.CompressZstd: ".zst"}[compress]), "unexpected backup %s", name)
+			contents = append(contents, readCompressed(t, filepath.Join(dir, name)))
+		}
+		testutils.Equals(t, []string{"line 2\n", "line 3\n"}, contents)
+
+		bs, err := os.ReadFile(filepath.Join(dir, "current.log"))
+		testutils.Ok(t, err)
+		testutils.Equals(t, "line 4\n", string(bs))
+	}
+}
+
+func TestFileLoggerMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "app.log_20200101000000")
+	testutils.Ok(t, os.WriteFile(old, []byte("old\n"), 0644))
+	past := time.Now().Add(-time.Hour * 48)
+	testutils.Ok(t, os.Chtimes(old, past, past))
+
+	w, err := logger.NewFileLogger(
+		logger.OptionFilename(filepath.Join(dir, "app.log")),
+		logger.OptionMaxLength(5),
+		logger.OptionMaxAge(time.Hour*24),
+	)
+	testutils.Ok(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = w.Write([]byte("new\n"))
+		testutils.Ok(t, err)
+	}
+	testutils.Ok(t, w.Close())
+
+	names := listDir(t, dir)
+	testutils.Equals(t, 2, len(names))
+	testutils.Assert(t, names[1] != filepath.Base(old), "the old backup should be removed")
+
+	_, err = logger.NewFileLogger(logger.OptionFilename(filepath.Join(dir, "app.log")), logger.OptionCompress("lz4"))
+	testutils.NotOk(t, err)
+}
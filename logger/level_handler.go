@@ -0,0 +1,124 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/iTrellis/common/json"
+)
+
+// levelState is the body of the level handler
+type levelState struct {
+	Level   Level            `json:"level"`
+	Modules map[string]Level `json:"modules"`
+}
+
+// levelRequest changes the levels, the module of the empty level follows its parent again
+type levelRequest struct {
+	Level   string            `json:"level,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+type levelHandler struct {
+	logger *ZapLogger
+}
+
+// LevelHandler returns the http handler of levels:
+// GET returns the levels, exp: {"level":"info","modules":{"db":"debug"}};
+// PUT changes the levels by the same body, the empty level of a module resets it,
+// exp: {"level":"warn","modules":{"db":"","http":"debug"}}
+func (p *ZapLogger) LevelHandler() http.Handler {
+	return &levelHandler{logger: p}
+}
+
+func (p *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if err := p.update(r.Body); err != nil {
+			p.write(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		p.write(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	p.write(w, http.StatusOK, &levelState{
+		Level:   p.logger.GetLevel(),
+		Modules: p.logger.ModuleLevels(),
+	})
+}
+
+// update parses all levels before they are changed
+func (p *levelHandler) update(body io.Reader) error {
+	bs, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	req := &levelRequest{}
+	if err := json.Unmarshal(bs, req); err != nil {
+		return err
+	}
+
+	var root *Level
+	if req.Level != "" {
+		lvl, err := ParseLevel(req.Level)
+		if err != nil {
+			return err
+		}
+		root = &lvl
+	}
+	modules := make(map[string]*Level, len(req.Modules))
+	for name, text := range req.Modules {
+		if text == "" {
+			modules[name] = nil
+			continue
+		}
+		lvl, err := ParseLevel(text)
+		if err != nil {
+			return err
+		}
+		modules[name] = &lvl
+	}
+
+	if root != nil {
+		p.logger.SetLevel(*root)
+	}
+	for name, lvl := range modules {
+		if lvl == nil {
+			p.logger.ResetModuleLevel(name)
+		} else {
+			p.logger.SetModuleLevel(name, *lvl)
+		}
+	}
+	return nil
+}
+
+func (p *levelHandler) write(w http.ResponseWriter, code int, v interface{}) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		code, bs = http.StatusInternalServerError, []byte(`{"error":"failed to marshal levels"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(bs)
+}
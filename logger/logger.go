@@ -20,6 +20,8 @@ package logger
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/iTrellis/common/json"
 	"go.uber.org/zap/zapcore"
@@ -34,7 +36,14 @@ type Logger interface {
 	SimpleLogger
 
 	With(kvs ...interface{}) Logger
+	// Named returns the child logger of the module, its level can be set independently
+	Named(name string) Logger
 
+	SetLevel(lvl Level)
+	GetLevel() Level
+
+	Trace(msg string, kvs ...interface{})
+	Tracef(msg string, kvs ...interface{})
 	Debug(msg string, kvs ...interface{}) // Debug(msg string, fields ...Field)
 	Debugf(msg string, kvs ...interface{})
 	Info(msg string, kvs ...interface{}) // Info(msg string, fields ...Field)
@@ -71,6 +80,7 @@ const (
 	LevelNamePanic   = "PANC"
 	LevelNameFatal   = "CRIT"
 
+	levelColorTrace = "\033[36m%s\033[0m" // cyan
 	levelColorDebug = "\033[32m%s\033[0m" // grenn
 	levelColorInfo  = "\033[37m%s\033[0m" // white
 	levelColorWarn  = "\033[34m%s\033[0m" // blue
@@ -79,10 +89,15 @@ const (
 	levelColorFatal = "\033[31m%s\033[0m" // red
 )
 
+// ZapTraceLevel the custom zap level of TraceLevel, it is below the debug level
+const ZapTraceLevel = zapcore.DebugLevel - 1
+
 // ToZapLevel  convert level into zap level
 func (p *Level) ToZapLevel() zapcore.Level {
 	switch *p {
-	case TraceLevel, DebugLevel:
+	case TraceLevel:
+		return ZapTraceLevel
+	case DebugLevel:
 		return zapcore.DebugLevel
 	case InfoLevel:
 		return zapcore.InfoLevel
@@ -99,9 +114,29 @@ func (p *Level) ToZapLevel() zapcore.Level {
 	}
 }
 
+// FromZapLevel convert zap level into level, levels below debug are trace
+func FromZapLevel(lvl zapcore.Level) Level {
+	switch {
+	case lvl < zapcore.DebugLevel:
+		return TraceLevel
+	case lvl == zapcore.DebugLevel:
+		return DebugLevel
+	case lvl == zapcore.InfoLevel:
+		return InfoLevel
+	case lvl == zapcore.WarnLevel:
+		return WarnLevel
+	case lvl == zapcore.ErrorLevel:
+		return ErrorLevel
+	case lvl == zapcore.FatalLevel:
+		return FatalLevel
+	default:
+		return PanicLevel
+	}
+}
+
 // LevelColors printer's color
 var LevelColors = map[Level]string{
-	TraceLevel: levelColorDebug,
+	TraceLevel: levelColorTrace,
 	DebugLevel: levelColorDebug,
 	InfoLevel:  levelColorInfo,
 	WarnLevel:  levelColorWarn,
@@ -132,6 +167,74 @@ func ToLevelName(lvl Level) string {
 	}
 }
 
+// levelTexts the texts of levels in configs and the level handler
+var levelTexts = map[Level]string{
+	TraceLevel: "trace",
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+	PanicLevel: "panic",
+	FatalLevel: "fatal",
+}
+
+// ParseLevel parse the level of the text: trace, debug, info, warn, error, panic, fatal,
+// the level names such as DEBU, or the number of the level
+func ParseLevel(text string) (Level, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for lvl, t := range levelTexts {
+		if text == t || text == strings.ToLower(ToLevelName(lvl)) {
+			return lvl, nil
+		}
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		if _, ok := levelTexts[Level(n)]; ok {
+			return Level(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level: %q", text)
+}
+
+// ParseModuleLevels parse the levels of modules, exp: db=debug,http=warn
+func ParseModuleLevels(text string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, item := range strings.Split(text, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid module level: %q", item)
+		}
+		lvl, err := ParseLevel(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = lvl
+	}
+	return levels, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Level) MarshalText() ([]byte, error) {
+	text, ok := levelTexts[p]
+	if !ok {
+		return nil, fmt.Errorf("unknown level: %d", p)
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, the text is parsed by ParseLevel
+func (p *Level) UnmarshalText(text []byte) error {
+	lvl, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*p = lvl
+	return nil
+}
+
 func toString(v interface{}) string {
 	switch reflect.TypeOf(v).Kind() {
 	case reflect.Ptr, reflect.Struct, reflect.Map:
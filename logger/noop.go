@@ -27,6 +27,8 @@ type noop struct{}
 func (noop) Log(keyvals ...interface{}) error {
 	return nil
 }
+func (noop) Trace(msg string, args ...interface{})  {}
+func (noop) Tracef(msg string, args ...interface{}) {}
 func (noop) Debug(msg string, args ...interface{})  {}
 func (noop) Debugf(msg string, args ...interface{}) {}
 func (noop) Infof(msg string, args ...interface{})  {}
@@ -42,3 +44,10 @@ func (noop) Fatalf(msg string, args ...interface{}) {}
 func (noop) With(...interface{}) Logger {
 	return &noop{}
 }
+func (noop) Named(string) Logger {
+	return &noop{}
+}
+func (noop) SetLevel(Level) {}
+func (noop) GetLevel() Level {
+	return FatalLevel
+}
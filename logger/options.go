@@ -19,6 +19,7 @@ package logger
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -59,19 +60,63 @@ type FileOptions struct {
 	MoveFileType MoveFileType `yaml:"move_file_type"`
 	// 最大保留日志个数，如果为0则全部保留
 	MaxBackups int `yaml:"max_backups"`
+	// 日志最长保留时间，如果为0则全部保留
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// 压缩移动后的文件: gzip | zstd, 默认不压缩
+	Compress string `yaml:"compress"`
+	// 移动后的文件名: {filename}, {name}, {ext}, {time}, 默认: {filename}_{time}
+	BackupPattern string `yaml:"backup_pattern"`
+	// 移动后的文件名中{time}的格式, 默认: 20060102150405.999999999
+	BackupTimeLayout string `yaml:"backup_time_layout"`
+	// 指向当前日志文件的软链接
+	Symlink string `yaml:"symlink"`
+
+	// 在后台异步写入日志文件
+	Async bool `yaml:"async"`
+	// 异步写入的缓冲行数, 默认: DefaultAsyncBufferSize
+	AsyncBufferSize int `yaml:"async_buffer_size"`
+	// 缓冲满时的处理方式: 0 阻塞, 1 丢弃最新的行, 2 丢弃最旧的行
+	Overflow OverflowPolicy `yaml:"overflow"`
 }
 
+// Compress types
+const (
+	CompressNone = ""
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// default backup names
+const (
+	DefaultBackupPattern    = "{filename}_{time}"
+	DefaultBackupTimeLayout = "20060102150405.999999999"
+)
+
 func (p *FileOptions) Check() error {
 	if p == nil || p.Filename == "" {
 		return errors.New("file name not exist")
 	}
 
+	switch p.Compress {
+	case CompressNone, CompressGzip, CompressZstd:
+	default:
+		return errors.New("unknown compress type")
+	}
+
+	if p.BackupPattern != "" && !strings.Contains(p.BackupPattern, "{time}") {
+		return errors.New("backup pattern should contain {time}")
+	}
+
 	return nil
 }
 
 type Option func(*LogConfig)
 type LogConfig struct {
-	Level      Level  `yaml:"level"`
+	Level Level `yaml:"level"`
+	// levels of the named loggers, exp: db: debug
+	ModuleLevels map[string]Level `yaml:"module_levels,omitempty"`
+
 	Encoding   string `yaml:"encoding,omitempty"` // json | console, default console
 	CallerSkip int    `yaml:"caller_skip"`
 	StackTrace bool   `yaml:"stack_trace"`
@@ -96,6 +141,25 @@ func LogLevel(lvl Level) Option {
 	}
 }
 
+// ModuleLevel 设置模块的等级
+func ModuleLevel(name string, lvl Level) Option {
+	return func(f *LogConfig) {
+		if f.ModuleLevels == nil {
+			f.ModuleLevels = make(map[string]Level)
+		}
+		f.ModuleLevels[name] = lvl
+	}
+}
+
+// ModuleLevels 设置多个模块的等级
+func ModuleLevels(levels map[string]Level) Option {
+	return func(f *LogConfig) {
+		for name, lvl := range levels {
+			ModuleLevel(name, lvl)(f)
+		}
+	}
+}
+
 // CallerSkip 设置等级
 func CallerSkip(cs int) Option {
 	return func(f *LogConfig) {
@@ -184,3 +248,44 @@ func OptionStdPrinters(ps []string) FileOption {
 		f.StdPrinters = ps
 	}
 }
+
+// OptionMaxAge 设置日志最长保留时间
+func OptionMaxAge(age time.Duration) FileOption {
+	return func(f *FileOptions) {
+		f.MaxAge = age
+	}
+}
+
+// OptionCompress 设置移动后文件的压缩类型: gzip | zstd
+func OptionCompress(compress string) FileOption {
+	return func(f *FileOptions) {
+		f.Compress = compress
+	}
+}
+
+// OptionBackupPattern 设置移动后的文件名, exp: {name}-{time}{ext}
+func OptionBackupPattern(pattern string, timeLayout ...string) FileOption {
+	return func(f *FileOptions) {
+		f.BackupPattern = pattern
+		if len(timeLayout) > 0 {
+			f.BackupTimeLayout = timeLayout[0]
+		}
+	}
+}
+
+// OptionSymlink 设置指向当前日志文件的软链接
+func OptionSymlink(symlink string) FileOption {
+	return func(f *FileOptions) {
+		f.Symlink = symlink
+	}
+}
+
+// OptionAsync 设置在后台异步写入日志文件, size为缓冲行数, policy为缓冲满时的处理方式,
+// 退出前需调用ZapLogger.Close写入缓冲的日志并关闭文件
+func OptionAsync(size int, policy OverflowPolicy) FileOption {
+	return func(f *FileOptions) {
+		f.Async = true
+		f.AsyncBufferSize = size
+		f.Overflow = policy
+	}
+}
@@ -38,6 +38,8 @@ func NewZapSugarLogger(logger *zap.Logger, level zapcore.Level, opts ...zap.Opti
 	sugarLogger := logger.WithOptions(opts...).Sugar()
 	var sugar zapSugarLogger
 	switch level {
+	case ZapTraceLevel:
+		sugar = traceLogger(logger.WithOptions(append(opts, zap.AddCallerSkip(1))...))
 	case zapcore.DebugLevel:
 		sugar = sugarLogger.Debugw
 	case zapcore.InfoLevel:
@@ -57,3 +59,12 @@ func NewZapSugarLogger(logger *zap.Logger, level zapcore.Level, opts ...zap.Opti
 	}
 	return sugar
 }
+
+// traceLogger logs key-values at the trace level, the sugar logger has no trace level
+func traceLogger(logger *zap.Logger) zapSugarLogger {
+	return func(msg string, kvs ...interface{}) {
+		if ce := logger.Check(ZapTraceLevel, msg); ce != nil {
+			ce.Write(toFields(kvs...)...)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LowercaseLevelEncoder serializes the level to a lowercase string, the trace level is trace
+func LowercaseLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == ZapTraceLevel {
+		enc.AppendString("trace")
+		return
+	}
+	zapcore.LowercaseLevelEncoder(l, enc)
+}
+
+// CapitalLevelEncoder serializes the level to an all-caps string, the trace level is TRACE
+func CapitalLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == ZapTraceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalLevelEncoder(l, enc)
+}
+
+// ColorLevelEncoder serializes the level to the level name with the color of LevelColors, exp: TRAC, DEBU
+func ColorLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	lvl := FromZapLevel(l)
+	enc.AppendString(fmt.Sprintf(LevelColors[lvl], ToLevelName(lvl)))
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// atomicLevel is the level of a logger which can be changed at runtime,
+// the level of the named logger follows its parent until it is set
+type atomicLevel struct {
+	level  int32
+	set    int32
+	parent *atomicLevel
+}
+
+func (p *atomicLevel) get() Level {
+	if p.parent != nil && atomic.LoadInt32(&p.set) == 0 {
+		return p.parent.get()
+	}
+	return Level(atomic.LoadInt32(&p.level))
+}
+
+func (p *atomicLevel) setLevel(lvl Level) {
+	atomic.StoreInt32(&p.level, int32(lvl))
+	atomic.StoreInt32(&p.set, 1)
+}
+
+// reset makes the level follow its parent again
+func (p *atomicLevel) reset() {
+	atomic.StoreInt32(&p.set, 0)
+}
+
+func (p *atomicLevel) isSet() bool {
+	return atomic.LoadInt32(&p.set) == 1
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (p *atomicLevel) Enabled(l zapcore.Level) bool {
+	lvl := p.get()
+	return lvl.ToZapLevel().Enabled(l)
+}
+
+// levels keeps the root level and the levels of named loggers
+type levels struct {
+	root *atomicLevel
+
+	locker  sync.Mutex
+	modules map[string]*atomicLevel
+}
+
+func newLevels(root Level) *levels {
+	p := &levels{root: &atomicLevel{}, modules: make(map[string]*atomicLevel)}
+	p.root.setLevel(root)
+	return p
+}
+
+// module returns the level of the named logger, exp: db.pool follows db, db follows the root
+func (p *levels) module(name string) *atomicLevel {
+	if name == "" {
+		return p.root
+	}
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	return p.getModule(name)
+}
+
+func (p *levels) getModule(name string) *atomicLevel {
+	lvl, ok := p.modules[name]
+	if ok {
+		return lvl
+	}
+
+	parent := p.root
+	if i := strings.LastIndex(name, "."); i > 0 {
+		parent = p.getModule(name[:i])
+	}
+	lvl = &atomicLevel{parent: parent}
+	p.modules[name] = lvl
+	return lvl
+}
+
+// moduleLevels returns the levels of modules which are set
+func (p *levels) moduleLevels() map[string]Level {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	modules := make(map[string]Level)
+	for name, lvl := range p.modules {
+		if lvl.isSet() {
+			modules[name] = lvl.get()
+		}
+	}
+	return modules
+}
+
+// levelCore checks the entries by the level which can be changed,
+// the wrapped core accepts all entries
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (p *levelCore) Enabled(lvl zapcore.Level) bool {
+	return p.level.Enabled(lvl)
+}
+
+func (p *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: p.Core.With(fields), level: p.level}
+}
+
+func (p *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !p.level.Enabled(ent.Level) {
+		return ce
+	}
+	return p.Core.Check(ent, ce)
+}
+
+// withLevel returns the core checked by the level
+func withLevel(core zapcore.Core, level zapcore.LevelEnabler) zapcore.Core {
+	if lc, ok := core.(*levelCore); ok {
+		core = lc.Core
+	}
+	return &levelCore{Core: core, level: level}
+}
@@ -20,6 +20,7 @@ package logger
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"go.uber.org/zap"
@@ -30,12 +31,37 @@ func NewWithZapLogger(l *zap.Logger) Logger {
 	if l == nil {
 		return &noop{}
 	}
-	return &ZapLogger{logger: l}
+
+	// the level is the lowest one enabled by the zap logger
+	lvl := FatalLevel
+	for _, next := range []Level{ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel} {
+		if !l.Core().Enabled(next.ToZapLevel()) {
+			break
+		}
+		lvl = next
+	}
+	levels := newLevels(lvl)
+	return &ZapLogger{
+		options: &LogConfig{Level: lvl},
+		levels:  levels,
+		level:   levels.root,
+		logger: l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return withLevel(c, levels.root)
+		})),
+	}
 }
 
 type ZapLogger struct {
 	options *LogConfig
 	logger  *zap.Logger
+
+	// name of the module, it is empty for the root logger
+	module string
+	levels *levels
+	level  *atomicLevel
+
+	// closers are the file writers, the async writer is before its file logger
+	closers []io.Closer
 }
 
 var _ Logger = (*ZapLogger)(nil)
@@ -57,7 +83,7 @@ func NewLogger(opts ...Option) (*ZapLogger, error) {
 			MessageKey:       "msg",
 			StacktraceKey:    "stacktrace",
 			LineEnding:       zapcore.DefaultLineEnding,
-			EncodeLevel:      zapcore.LowercaseLevelEncoder,
+			EncodeLevel:      LowercaseLevelEncoder,
 			EncodeTime:       zapcore.RFC3339NanoTimeEncoder,
 			EncodeDuration:   zapcore.SecondsDurationEncoder,
 			EncodeCaller:     zapcore.ShortCallerEncoder,
@@ -65,7 +91,11 @@ func NewLogger(opts ...Option) (*ZapLogger, error) {
 		}
 	}
 
-	level := zap.NewAtomicLevelAt(zl.options.Level.ToZapLevel())
+	zl.levels = newLevels(zl.options.Level)
+	zl.level = zl.levels.root
+	for name, lvl := range zl.options.ModuleLevels {
+		zl.levels.module(name).setLevel(lvl)
+	}
 
 	var encoder zapcore.Encoder
 	switch zl.options.Encoding {
@@ -98,10 +128,19 @@ func NewLogger(opts ...Option) (*ZapLogger, error) {
 		if err != nil {
 			return nil, err
 		}
-		ws = append(ws, w)
+		if zl.options.FileOptions.Async {
+			aw := NewAsyncWriter(w, zl.options.FileOptions.AsyncBufferSize, zl.options.FileOptions.Overflow)
+			ws = append(ws, aw)
+			zl.closers = append(zl.closers, aw)
+		} else {
+			ws = append(ws, w)
+		}
+		zl.closers = append(zl.closers, w)
 	}
 
-	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(ws...), level)
+	// the core accepts all entries, they are checked by the levels of loggers
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(ws...),
+		zap.LevelEnablerFunc(func(zapcore.Level) bool { return true }))
 
 	var options []zap.Option
 	if zl.options.CallerSkip != 0 {
@@ -109,25 +148,94 @@ func NewLogger(opts ...Option) (*ZapLogger, error) {
 	}
 
 	if zl.options.StackTrace {
-		options = append(options, zap.AddStacktrace(level))
+		options = append(options, zap.AddStacktrace(zl.levels.root))
 	}
 
 	if zl.options.Caller {
 		options = append(options, zap.AddCaller())
 	}
 
-	zl.logger = zap.New(core, options...)
+	zl.logger = zap.New(withLevel(core, zl.level), options...)
 	return zl, nil
 }
 
+// SetLevel set the level of the logger at runtime,
+// the level of the named logger overrides the level of its parent
+func (p *ZapLogger) SetLevel(lvl Level) {
+	p.level.setLevel(lvl)
+}
+
+// GetLevel returns the level of the logger
+func (p *ZapLogger) GetLevel() Level {
+	return p.level.get()
+}
+
+// Named returns the child logger of the module, exp: Named("db").Named("pool") is the module db.pool;
+// its level follows the parent until it is set by SetLevel, SetModuleLevel or the module levels in options
+func (p *ZapLogger) Named(name string) Logger {
+	module := name
+	if p.module != "" {
+		module = p.module + "." + name
+	}
+	level := p.levels.module(module)
+	return &ZapLogger{
+		options: p.options,
+		module:  module,
+		levels:  p.levels,
+		level:   level,
+		closers: p.closers,
+		logger: p.logger.Named(name).WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return withLevel(c, level)
+		})),
+	}
+}
+
+// SetModuleLevel set the level of the named logger of the module
+func (p *ZapLogger) SetModuleLevel(module string, lvl Level) {
+	p.levels.module(module).setLevel(lvl)
+}
+
+// ResetModuleLevel makes the level of the named logger of the module follow its parent
+func (p *ZapLogger) ResetModuleLevel(module string) {
+	if module == "" {
+		return
+	}
+	p.levels.module(module).reset()
+}
+
+// ModuleLevels returns the levels of modules which are set
+func (p *ZapLogger) ModuleLevels() map[string]Level {
+	return p.levels.moduleLevels()
+}
+
 func (p *ZapLogger) GetZapLogger() *zap.Logger {
 	return p.logger
 }
 
+// Sync flushes the buffered logs, it waits for the logs written by the async writer
+func (p *ZapLogger) Sync() error {
+	return p.logger.Sync()
+}
+
+// Close writes the buffered logs of the async writer and closes the log file,
+// the named loggers share the file with their parent, so they should not be used after it
+func (p *ZapLogger) Close() error {
+	var err error
+	for _, c := range p.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 // With (fields ...Field)
 func (p *ZapLogger) With(kvs ...interface{}) Logger {
 	newZL := &ZapLogger{
 		options: p.options,
+		module:  p.module,
+		levels:  p.levels,
+		level:   p.level,
 	}
 
 	lenFields := len(kvs)
@@ -151,6 +259,18 @@ func (p *ZapLogger) Log(kvs ...interface{}) error {
 	return nil
 }
 
+// Trace logs the message at the trace level which is below the debug level
+func (p *ZapLogger) Trace(msg string, kvs ...interface{}) {
+	if ce := p.logger.Check(ZapTraceLevel, msg); ce != nil {
+		ce.Write(p.genKVs(kvs...)...)
+	}
+}
+
+// Tracef(msg string, fields ...Field)
+func (p *ZapLogger) Tracef(msg string, kvs ...interface{}) {
+	p.Trace(fmt.Sprintf(msg, kvs...))
+}
+
 // Debug(msg string, fields ...Field)
 func (p *ZapLogger) Debug(msg string, kvs ...interface{}) {
 	fields := p.genKVs(kvs...)
@@ -223,6 +343,10 @@ func (p *ZapLogger) Fatalf(msg string, kvs ...interface{}) {
 }
 
 func (p *ZapLogger) genKVs(kvs ...interface{}) []zap.Field {
+	return toFields(kvs...)
+}
+
+func toFields(kvs ...interface{}) []zap.Field {
 
 	lenFields := len(kvs)
 	n := 4 + (lenFields+1)/2*2
@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iTrellis/common/logger"
+	"github.com/iTrellis/common/testutils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger(lvl zapcore.Level) (*logger.ZapLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(lvl)
+	return logger.NewWithZapLogger(zap.New(core)).(*logger.ZapLogger), logs
+}
+
+func TestParseLevel(t *testing.T) {
+	for text, lvl := range map[string]logger.Level{
+		"debug": logger.DebugLevel, " WARN": logger.WarnLevel, "ERRO": logger.ErrorLevel, "3": logger.WarnLevel,
+	} {
+		got, err := logger.ParseLevel(text)
+		testutils.Ok(t, err)
+		testutils.Equals(t, lvl, got)
+	}
+	_, err := logger.ParseLevel("verbose")
+	testutils.NotOk(t, err)
+
+	levels, err := logger.ParseModuleLevels("db=debug, http=warn")
+	testutils.Ok(t, err)
+	testutils.Equals(t, map[string]logger.Level{"db": logger.DebugLevel, "http": logger.WarnLevel}, levels)
+	_, err = logger.ParseModuleLevels("db")
+	testutils.NotOk(t, err)
+}
+
+func TestSetLevel(t *testing.T) {
+	l, logs := newObservedLogger(zapcore.DebugLevel)
+	testutils.Equals(t, logger.DebugLevel, l.GetLevel())
+
+	l.Debug("debug")
+	l.SetLevel(logger.WarnLevel)
+	l.Info("info")
+	l.With("k", "v").Warn("warn")
+	testutils.Equals(t, logger.WarnLevel, l.GetLevel())
+	testutils.Equals(t, 2, logs.Len())
+
+	info, _ := newObservedLogger(zapcore.InfoLevel)
+	testutils.Equals(t, logger.InfoLevel, info.GetLevel())
+}
+
+func TestModuleLevels(t *testing.T) {
+	l, logs := newObservedLogger(zapcore.DebugLevel)
+	l.SetLevel(logger.InfoLevel)
+
+	db := l.Named("db")
+	pool := db.Named("pool")
+	web := l.Named("http").With("k", "v")
+
+	l.SetModuleLevel("db", logger.DebugLevel)
+	l.SetModuleLevel("http", logger.WarnLevel)
+
+	db.Debug("db debug")
+	pool.Debug("pool debug follows db")
+	web.Info("http info is dropped")
+	l.Debug("root debug is dropped")
+	testutils.Equals(t, 2, logs.Len())
+	testutils.Equals(t, "db.pool", logs.All()[1].LoggerName)
+
+	pool.SetLevel(logger.ErrorLevel)
+	pool.Warn("pool warn is dropped")
+	l.ResetModuleLevel("db.pool")
+	pool.Debug("pool debug follows db again")
+	testutils.Equals(t, 3, logs.Len())
+	testutils.Equals(t, map[string]logger.Level{"db": logger.DebugLevel, "http": logger.WarnLevel}, l.ModuleLevels())
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, _ := newObservedLogger(zapcore.DebugLevel)
+	l.SetModuleLevel("db", logger.DebugLevel)
+	h := l.LevelHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+	testutils.Equals(t, http.StatusOK, w.Code)
+	testutils.Equals(t, `{"level":"debug","modules":{"db":"debug"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level",
+		strings.NewReader(`{"level":"warn","modules":{"db":"","http":"error"}}`)))
+	testutils.Equals(t, http.StatusOK, w.Code)
+	testutils.Equals(t, `{"level":"warn","modules":{"http":"error"}}`, w.Body.String())
+	testutils.Equals(t, logger.WarnLevel, l.Named("db").GetLevel())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"verbose"}`)))
+	testutils.Equals(t, http.StatusBadRequest, w.Code)
+	testutils.Equals(t, logger.WarnLevel, l.GetLevel())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/log/level", nil))
+	testutils.Equals(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestNewLoggerModuleLevels(t *testing.T) {
+	l, err := logger.NewLogger(logger.LogLevel(logger.InfoLevel), logger.ModuleLevel("db", logger.DebugLevel),
+		logger.LogFileOption(logger.OptionStdPrinters([]string{"stdout"})))
+	testutils.Ok(t, err)
+	testutils.Equals(t, logger.InfoLevel, l.GetLevel())
+	testutils.Equals(t, logger.DebugLevel, l.Named("db").GetLevel())
+	testutils.Equals(t, logger.InfoLevel, l.Named("http").GetLevel())
+}
+
+func TestTraceLevel(t *testing.T) {
+	l, logs := newObservedLogger(logger.ZapTraceLevel)
+	testutils.Equals(t, logger.TraceLevel, l.GetLevel())
+
+	l.Tracef("trace %d", 1)
+	l.SetLevel(logger.DebugLevel)
+	l.Trace("trace is dropped")
+	l.Debug("debug")
+	testutils.Equals(t, 2, logs.Len())
+	testutils.Equals(t, logger.ZapTraceLevel, logs.All()[0].Level)
+	testutils.Equals(t, "trace 1", logs.All()[0].Message)
+
+	buf := &bytes.Buffer{}
+	enc := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		LevelKey: "level", MessageKey: "msg", EncodeLevel: logger.LowercaseLevelEncoder})
+	core := zapcore.NewCore(enc, zapcore.AddSync(buf), logger.ZapTraceLevel)
+	logger.NewWithZapLogger(zap.New(core)).Trace("msg")
+	testutils.Equals(t, "trace\tmsg\n", buf.String())
+
+	sugar := logger.NewZapSugarLogger(zap.New(core), logger.ZapTraceLevel)
+	testutils.Ok(t, sugar.Log("k", "v"))
+	testutils.Equals(t, "trace\tmsg\ntrace\t\t{\"k\": \"v\"}\n", buf.String())
+
+	testutils.Equals(t, logger.TraceLevel, logger.FromZapLevel(logger.ZapTraceLevel))
+	lvl := logger.TraceLevel
+	testutils.Equals(t, logger.ZapTraceLevel, lvl.ToZapLevel())
+}